@@ -0,0 +1,297 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+)
+
+// LoggerState describes a registered logger for the admin handler
+type LoggerState struct {
+	Name string `json:"name"`
+	// the effective level
+	Level string `json:"level"`
+	// the level set for this name, empty when inherited or the default
+	Explicit string `json:"explicit,omitempty"`
+	// the ancestor the level is inherited from
+	InheritedFrom string `json:"inherited_from,omitempty"`
+	Disabled      bool   `json:"disabled"`
+}
+
+// the state of every registered logger sorted by name
+func LoggerStates() []LoggerState {
+	states := []LoggerState{}
+	for name, logger := range ListLogger() {
+		state := LoggerState{Name: name, Level: logger.GetLevel()}
+		if level, from := effectiveLevel(name); from == name {
+			state.Explicit = level
+		} else if from != "" {
+			state.InheritedFrom = from
+		}
+		outMux.RLock()
+		orig, found := disabled[name]
+		outMux.RUnlock()
+		if found {
+			state.Disabled = true
+			state.Level = orig.GetLevel()
+		}
+		states = append(states, state)
+	}
+	sort.Slice(states, func(i, j int) bool {
+		return states[i].Name < states[j].Name
+	})
+	return states
+}
+
+// LevelChange selects loggers by Name, by glob Pattern (as DisableLogs) or All and
+// sets their Level and/or disables or enables them
+type LevelChange struct {
+	Name     string `json:"name,omitempty"`
+	Pattern  string `json:"pattern,omitempty"`
+	All      bool   `json:"all,omitempty"`
+	Level    string `json:"level,omitempty"`
+	Disabled *bool  `json:"disabled,omitempty"`
+}
+
+// apply c to the registry, returns the names of the loggers changed
+func ApplyLevelChange(c LevelChange) ([]string, error) {
+	if c.Level == "" && c.Disabled == nil {
+		return nil, fmt.Errorf("nothing to change, set level or disabled")
+	}
+	if c.Level != "" {
+		if _, err := LevelFromString(c.Level); err != nil {
+			return nil, err
+		}
+	}
+	var match func(name string) bool
+	switch {
+	case c.All:
+		match = func(string) bool { return true }
+	case c.Pattern != "":
+		if _, err := path.Match(c.Pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %s", c.Pattern, err)
+		}
+		match = func(name string) bool {
+			matched, _ := path.Match(c.Pattern, name)
+			return matched
+		}
+	case c.Name != "":
+		match = func(name string) bool { return name == c.Name }
+	default:
+		return nil, fmt.Errorf("no loggers selected, set name, pattern or all")
+	}
+
+	names := []string{}
+	for name := range ListLogger() {
+		if match(name) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if c.Disabled != nil && !*c.Disabled {
+			EnableLog(name)
+		}
+		if c.Level != "" {
+			if err := SetLevel(name, c.Level); err != nil {
+				return names, err
+			}
+		}
+		if c.Disabled != nil && *c.Disabled {
+			DisableLog(name)
+		}
+	}
+	return names, nil
+}
+
+// NewAdminHandler returns a http.Handler to inspect and change log levels at runtime.
+//
+// GET lists every logger as json, or as html for browsers, name or pattern query
+// parameters select which. PUT or POST a json
+// LevelChange, or the same fields as form values, to change loggers. changes from
+// another origin are refused so other web pages can not post to it
+//
+//	http.Handle("/debug/logging", gologging.NewAdminHandler())
+//	curl -X PUT -H 'Content-Type: application/json' -d '{"pattern":"db.*","level":"trace"}' localhost:8080/debug/logging
+func NewAdminHandler() http.Handler {
+	return http.HandlerFunc(serveAdmin)
+}
+
+func serveAdmin(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+		states := filterStates(LoggerStates(), r.URL.Query().Get("name"), r.URL.Query().Get("pattern"))
+		if wantsHTML(r) {
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			adminTemplate.Execute(w, states)
+			return
+		}
+		writeAdminJSON(w, http.StatusOK, states)
+	case http.MethodPut, http.MethodPost:
+		if err := checkSameOrigin(r); err != nil {
+			writeAdminError(w, http.StatusForbidden, err)
+			return
+		}
+		c, err := parseLevelChange(r)
+		if err != nil {
+			status := http.StatusBadRequest
+			if err == errAdminContentType {
+				status = http.StatusUnsupportedMediaType
+			}
+			writeAdminError(w, status, err)
+			return
+		}
+		names, err := ApplyLevelChange(c)
+		if err != nil {
+			writeAdminError(w, http.StatusBadRequest, err)
+			return
+		}
+		if len(names) == 0 {
+			writeAdminError(w, http.StatusNotFound, fmt.Errorf("no loggers matched"))
+			return
+		}
+		if wantsHTML(r) {
+			http.Redirect(w, r, r.URL.Path, http.StatusSeeOther)
+			return
+		}
+		writeAdminJSON(w, http.StatusOK, LoggerStates())
+	default:
+		w.Header().Set("Allow", "GET, HEAD, PUT, POST")
+		writeAdminError(w, http.StatusMethodNotAllowed, fmt.Errorf("method not allowed"))
+	}
+}
+
+// keep the loggers named name or matching pattern, everything when both are empty
+func filterStates(states []LoggerState, name string, pattern string) []LoggerState {
+	if name == "" && pattern == "" {
+		return states
+	}
+	filtered := []LoggerState{}
+	for _, s := range states {
+		matched, _ := path.Match(pattern, s.Name)
+		if s.Name == name || (pattern != "" && matched) {
+			filtered = append(filtered, s)
+		}
+	}
+	return filtered
+}
+
+func wantsHTML(r *http.Request) bool {
+	if f := r.URL.Query().Get("format"); f != "" {
+		return f == "html"
+	}
+	return strings.Contains(r.Header.Get("Accept"), "text/html")
+}
+
+// browsers send Sec-Fetch-Site and Origin with a post, tools like curl send neither
+func checkSameOrigin(r *http.Request) error {
+	switch site := r.Header.Get("Sec-Fetch-Site"); site {
+	case "", "same-origin", "none":
+	default:
+		return fmt.Errorf("cross origin request refused: Sec-Fetch-Site %s", site)
+	}
+	if origin := r.Header.Get("Origin"); origin != "" {
+		u, err := url.Parse(origin)
+		if err != nil || u.Host != r.Host {
+			return fmt.Errorf("cross origin request refused: Origin %s", origin)
+		}
+	}
+	return nil
+}
+
+var errAdminContentType = errors.New("unsupported content type, use application/json or application/x-www-form-urlencoded")
+
+// the body is json for an application/json content type, otherwise form values
+// from the body and query string are used
+func parseLevelChange(r *http.Request) (LevelChange, error) {
+	var c LevelChange
+	mediatype := ""
+	if ct := r.Header.Get("Content-Type"); ct != "" {
+		var err error
+		if mediatype, _, err = mime.ParseMediaType(ct); err != nil {
+			return c, errAdminContentType
+		}
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, 1<<20))
+	if err != nil {
+		return c, err
+	}
+	switch mediatype {
+	case "application/json":
+		err := json.Unmarshal(body, &c)
+		return c, err
+	case "application/x-www-form-urlencoded":
+	case "":
+		// only the query string, eg. curl -X PUT 'localhost:8080/debug/logging?name=db&level=trace'
+		if len(bytes.TrimSpace(body)) > 0 {
+			return c, errAdminContentType
+		}
+	default:
+		return c, errAdminContentType
+	}
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		return c, err
+	}
+	for k, v := range r.URL.Query() {
+		if _, found := form[k]; !found {
+			form[k] = v
+		}
+	}
+	c.Name = form.Get("name")
+	c.Pattern = form.Get("pattern")
+	c.All = form.Get("all") == "true"
+	c.Level = form.Get("level")
+	switch form.Get("disabled") {
+	case "true":
+		disable := true
+		c.Disabled = &disable
+	case "false":
+		disable := false
+		c.Disabled = &disable
+	}
+	return c, nil
+}
+
+func writeAdminJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.Encode(v)
+}
+
+func writeAdminError(w http.ResponseWriter, status int, err error) {
+	writeAdminJSON(w, status, map[string]string{"error": err.Error()})
+}
+
+var adminTemplate = template.Must(template.New("admin").Funcs(template.FuncMap{
+	"levels": func() []string {
+		return []string{"trace", "debug", "info", "warning", "error", "critical"}
+	},
+	"lower": strings.ToLower,
+}).Parse(`<!DOCTYPE html>
+<html><head><title>loggers</title></head><body>
+<table>
+<tr><th>name</th><th>level</th><th>from</th><th>disabled</th><th></th></tr>
+{{range .}}<tr>
+<td>{{.Name}}</td><td>{{.Level}}</td><td>{{if .Explicit}}explicit{{else if .InheritedFrom}}{{.InheritedFrom}}{{else}}default{{end}}</td><td>{{.Disabled}}</td>
+<td><form method="post"><input type="hidden" name="name" value="{{.Name}}">
+<select name="level">{{$level := lower .Level}}{{range levels}}<option{{if eq . $level}} selected{{end}}>{{.}}</option>{{end}}</select>
+<button>set</button></form></td>
+<td><form method="post"><input type="hidden" name="name" value="{{.Name}}">
+<input type="hidden" name="disabled" value="{{if .Disabled}}false{{else}}true{{end}}">
+<button>{{if .Disabled}}enable{{else}}disable{{end}}</button></form></td>
+</tr>{{end}}
+</table>
+</body></html>
+`))
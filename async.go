@@ -0,0 +1,323 @@
+package logging
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// what AsyncWriter does with a record when its queue is full
+type AsyncPolicy int
+
+const (
+	// wait for room in the queue
+	AsyncBlock AsyncPolicy = iota
+	// drop the record being written
+	AsyncDropNewest
+	// drop the oldest queued record to make room
+	AsyncDropOldest
+	// drop records below AsyncOptions.Level, wait for room for the others
+	AsyncDropBelowLevel
+)
+
+type AsyncOptions struct {
+	// records queued, defaults to 1024. ERROR and CRITICAL records have their own
+	// queue of the same size
+	QueueSize int
+	Policy    AsyncPolicy
+	// used by AsyncDropBelowLevel
+	Level int
+	// encodes records for a writer which is not a RecordWriter, defaults to the
+	// encoder of the logger writing the record, or a TextEncoder
+	Encoder Encoder
+	// how often the number of dropped records is written, defaults to 10s
+	ReportInterval time.Duration
+	// how long Flush and Close wait for the queue to drain, defaults to 5s
+	DrainTimeout time.Duration
+}
+
+// AsyncWriter writes records on its own goroutine so a slow disk or pipe does not
+// stall the logging goroutine. ERROR and CRITICAL records are never dropped and are
+// written ahead of queued records at lower levels. dropped records are counted, and
+// every ReportInterval and on Close a new count is written straight to the wrapped
+// writer as a WARNING record named go-logging. records are copied when queued but
+// the values of their fields are not, so those must not be changed once logged
+//
+//	w := gologging.NewAsyncWriter(f, gologging.AsyncOptions{Policy: gologging.AsyncDropOldest})
+//	gologging.SetLogOutput(w)
+//	defer w.Close()
+type AsyncWriter struct {
+	w    io.Writer
+	opts AsyncOptions
+
+	mu       sync.Mutex
+	cond     *sync.Cond
+	queue    []asyncItem
+	priority []asyncItem
+	// the worker is writing an item taken off a queue
+	busy   bool
+	closed bool
+	done   chan struct{}
+	// set every ReportInterval for the worker to report dropped records
+	reportDue bool
+
+	dropped  atomic.Uint64
+	reported uint64
+	// the encoder of the last record written, used for the dropped record report
+	lastEncoder Encoder
+}
+
+// a record with the encoder of its logger, or preformatted bytes from Write
+type asyncItem struct {
+	r   *Record
+	enc Encoder
+	p   []byte
+}
+
+func NewAsyncWriter(w io.Writer, opts AsyncOptions) *AsyncWriter {
+	if opts.QueueSize <= 0 {
+		opts.QueueSize = 1024
+	}
+	if opts.ReportInterval <= 0 {
+		opts.ReportInterval = 10 * time.Second
+	}
+	if opts.DrainTimeout <= 0 {
+		opts.DrainTimeout = 5 * time.Second
+	}
+	a := &AsyncWriter{
+		w:           w,
+		opts:        opts,
+		done:        make(chan struct{}),
+		lastEncoder: NewTextEncoder(),
+	}
+	a.cond = sync.NewCond(&a.mu)
+	go a.run()
+	go a.tick()
+	return a
+}
+
+// wake the worker to report dropped records, also when nothing is written
+func (a *AsyncWriter) tick() {
+	t := time.NewTicker(a.opts.ReportInterval)
+	defer t.Stop()
+	for {
+		select {
+		case <-t.C:
+			a.mu.Lock()
+			a.reportDue = true
+			a.cond.Broadcast()
+			a.mu.Unlock()
+		case <-a.done:
+			return
+		}
+	}
+}
+
+// the number of records dropped so far
+func (a *AsyncWriter) Dropped() uint64 {
+	return a.dropped.Load()
+}
+
+func (a *AsyncWriter) WriteRecord(r *Record) error {
+	return a.writeRecordWith(r, nil)
+}
+
+func (a *AsyncWriter) writeRecordWith(r *Record, encoder Encoder) error {
+	c := *r
+	c.Fields = append([]Field(nil), r.Fields...)
+	return a.enqueue(asyncItem{r: &c, enc: encoder}, r.Level)
+}
+
+// the bytes are copied, p may be reused by the caller
+func (a *AsyncWriter) Write(p []byte) (int, error) {
+	item := asyncItem{p: append([]byte(nil), p...)}
+	if err := a.enqueue(item, INFO); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+var errAsyncClosed = errors.New("AsyncWriter: closed")
+
+func (a *AsyncWriter) enqueue(item asyncItem, level int) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if level >= ERROR {
+		for len(a.priority) >= a.opts.QueueSize && !a.closed {
+			a.cond.Wait()
+		}
+		if a.closed {
+			return errAsyncClosed
+		}
+		a.priority = append(a.priority, item)
+		a.cond.Broadcast()
+		return nil
+	}
+	for len(a.queue) >= a.opts.QueueSize && !a.closed {
+		switch {
+		case a.opts.Policy == AsyncDropNewest,
+			a.opts.Policy == AsyncDropBelowLevel && level < a.opts.Level:
+			a.dropped.Add(1)
+			return nil
+		case a.opts.Policy == AsyncDropOldest:
+			a.queue = a.queue[1:]
+			a.dropped.Add(1)
+			continue
+		}
+		a.cond.Wait()
+	}
+	if a.closed {
+		return errAsyncClosed
+	}
+	a.queue = append(a.queue, item)
+	a.cond.Broadcast()
+	return nil
+}
+
+func (a *AsyncWriter) run() {
+	defer close(a.done)
+	a.mu.Lock()
+	for {
+		for len(a.priority) == 0 && len(a.queue) == 0 && !a.closed && !a.reportDue {
+			a.cond.Wait()
+		}
+		if a.reportDue {
+			a.reportDue = false
+			a.busy = true
+			a.mu.Unlock()
+			a.reportDropped()
+			a.mu.Lock()
+			a.busy = false
+			a.cond.Broadcast()
+			continue
+		}
+		var item asyncItem
+		switch {
+		case len(a.priority) > 0:
+			item = a.priority[0]
+			a.priority = a.priority[1:]
+		case len(a.queue) > 0:
+			item = a.queue[0]
+			a.queue = a.queue[1:]
+		default:
+			// closed and drained
+			a.mu.Unlock()
+			return
+		}
+		a.busy = true
+		// wake writers waiting for room
+		a.cond.Broadcast()
+		a.mu.Unlock()
+
+		a.write(item)
+
+		a.mu.Lock()
+		a.busy = false
+		a.cond.Broadcast()
+	}
+}
+
+func (a *AsyncWriter) write(item asyncItem) error {
+	if item.r == nil {
+		_, err := a.w.Write(item.p)
+		return err
+	}
+	if rw, ok := a.w.(RecordWriter); ok {
+		return writeRecordTo(rw, item.r, item.enc)
+	}
+	encoder := a.opts.Encoder
+	if encoder == nil {
+		encoder = item.enc
+	}
+	if encoder == nil {
+		encoder = a.lastEncoder
+	}
+	a.lastEncoder = encoder
+	_, err := a.w.Write(encoder.Encode(item.r))
+	return err
+}
+
+// write how many records were dropped since the last report, only called from
+// the worker or once it has stopped
+func (a *AsyncWriter) reportDropped() {
+	dropped := a.dropped.Load()
+	if dropped == a.reported {
+		return
+	}
+	r := newRecord(1, WARNING, "go-logging", "AsyncWriter dropped records",
+		[]Field{{Key: "dropped", Value: dropped - a.reported}, {Key: "total", Value: dropped}})
+	a.reported = dropped
+	a.write(asyncItem{r: r, enc: a.lastEncoder})
+}
+
+// wait until everything queued is written or the deadline passes
+func (a *AsyncWriter) drain(deadline time.Time) error {
+	// wake the wait below at the deadline
+	t := time.AfterFunc(time.Until(deadline), func() {
+		a.mu.Lock()
+		a.cond.Broadcast()
+		a.mu.Unlock()
+	})
+	defer t.Stop()
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for len(a.priority) > 0 || len(a.queue) > 0 || a.busy {
+		if !time.Now().Before(deadline) {
+			return fmt.Errorf("AsyncWriter: %d records not written within %s", len(a.priority)+len(a.queue), a.opts.DrainTimeout)
+		}
+		a.cond.Wait()
+	}
+	return nil
+}
+
+// wait up to DrainTimeout for the queue to be written, then flush the writer
+func (a *AsyncWriter) Flush() error {
+	if err := a.drain(time.Now().Add(a.opts.DrainTimeout)); err != nil {
+		return err
+	}
+	if f, ok := a.w.(flusher); ok {
+		return f.Flush()
+	}
+	return nil
+}
+
+// drain the queue as Flush, report dropped records and close the writer. records
+// still queued at the deadline are dropped. stdout and stderr are left open
+func (a *AsyncWriter) Close() error {
+	deadline := time.Now().Add(a.opts.DrainTimeout)
+	err := a.drain(deadline)
+
+	a.mu.Lock()
+	if a.closed {
+		a.mu.Unlock()
+		return err
+	}
+	a.closed = true
+	a.dropped.Add(uint64(len(a.priority) + len(a.queue)))
+	a.priority = nil
+	a.queue = nil
+	a.cond.Broadcast()
+	a.mu.Unlock()
+
+	select {
+	case <-a.done:
+	case <-time.After(time.Until(deadline)):
+		// the writer is stuck, leave it to the worker
+		return errors.Join(err, fmt.Errorf("AsyncWriter: writer did not finish within %s", a.opts.DrainTimeout))
+	}
+	a.reportDropped()
+	if f, ok := a.w.(flusher); ok {
+		err = errors.Join(err, f.Flush())
+	}
+	if a.w == os.Stdout || a.w == os.Stderr {
+		return err
+	}
+	if c, ok := a.w.(io.Closer); ok {
+		err = errors.Join(err, c.Close())
+	}
+	return err
+}
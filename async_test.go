@@ -0,0 +1,215 @@
+package logging
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// a writer holding every write until the gate is opened, started gets a value as
+// each write begins
+type gateWriter struct {
+	mu      sync.Mutex
+	lines   []string
+	started chan struct{}
+	gate    chan struct{}
+}
+
+func newGateWriter() *gateWriter {
+	return &gateWriter{started: make(chan struct{}, 100), gate: make(chan struct{})}
+}
+
+func (w *gateWriter) Write(p []byte) (int, error) {
+	w.started <- struct{}{}
+	<-w.gate
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.lines = append(w.lines, strings.TrimSuffix(string(p), "\n"))
+	return len(p), nil
+}
+
+func (w *gateWriter) written() []string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return append([]string(nil), w.lines...)
+}
+
+// only the message, to compare what was written
+type messageEncoder struct{}
+
+func (messageEncoder) Encode(r *Record) []byte {
+	return []byte(r.Message + "\n")
+}
+
+// write "1" and wait until the worker holds it, so the next records queue up
+func startBlocked(t *testing.T, opts AsyncOptions) (*AsyncWriter, *gateWriter) {
+	t.Helper()
+	w := newGateWriter()
+	opts.Encoder = messageEncoder{}
+	opts.ReportInterval = time.Hour
+	a := NewAsyncWriter(w, opts)
+	a.WriteRecord(testRecord(INFO, "", "1"))
+	<-w.started
+	return a, w
+}
+
+func TestAsyncPolicies(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    AsyncOptions
+		records []*Record
+		want    string
+		dropped uint64
+	}{
+		{
+			name:    "drop newest",
+			opts:    AsyncOptions{QueueSize: 2, Policy: AsyncDropNewest},
+			records: []*Record{testRecord(INFO, "", "2"), testRecord(INFO, "", "3"), testRecord(INFO, "", "4"), testRecord(INFO, "", "5")},
+			want:    "1 2 3",
+			dropped: 2,
+		},
+		{
+			name:    "drop oldest",
+			opts:    AsyncOptions{QueueSize: 2, Policy: AsyncDropOldest},
+			records: []*Record{testRecord(INFO, "", "2"), testRecord(INFO, "", "3"), testRecord(INFO, "", "4"), testRecord(INFO, "", "5")},
+			want:    "1 4 5",
+			dropped: 2,
+		},
+		{
+			name:    "drop below level",
+			opts:    AsyncOptions{QueueSize: 2, Policy: AsyncDropBelowLevel, Level: WARNING},
+			records: []*Record{testRecord(INFO, "", "2"), testRecord(INFO, "", "3"), testRecord(INFO, "", "4"), testRecord(WARNING, "", "5")},
+			want:    "1 2 3 5",
+			dropped: 1,
+		},
+		{
+			name:    "block",
+			opts:    AsyncOptions{QueueSize: 2, Policy: AsyncBlock},
+			records: []*Record{testRecord(INFO, "", "2"), testRecord(INFO, "", "3"), testRecord(INFO, "", "4")},
+			want:    "1 2 3 4",
+		},
+		{
+			name:    "errors first and never dropped",
+			opts:    AsyncOptions{QueueSize: 2, Policy: AsyncDropNewest},
+			records: []*Record{testRecord(INFO, "", "2"), testRecord(INFO, "", "3"), testRecord(ERROR, "", "e1"), testRecord(CRITICAL, "", "e2"), testRecord(INFO, "", "4")},
+			want:    "1 e1 e2 2 3",
+			dropped: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, w := startBlocked(t, tt.opts)
+			done := make(chan struct{})
+			go func() {
+				// a blocked write returns once the gate opens
+				for _, r := range tt.records {
+					a.WriteRecord(r)
+				}
+				close(done)
+			}()
+			if tt.opts.Policy != AsyncBlock && tt.opts.Policy != AsyncDropBelowLevel {
+				<-done
+			} else {
+				time.Sleep(20 * time.Millisecond)
+			}
+			close(w.gate)
+			<-done
+			if err := a.Close(); err != nil {
+				t.Fatal(err)
+			}
+			want := tt.want
+			if tt.dropped > 0 {
+				// the count is reported on Close
+				want += " AsyncWriter dropped records"
+			}
+			if got := strings.Join(w.written(), " "); got != want {
+				t.Errorf("written: %s, want %s", got, want)
+			}
+			if got := a.Dropped(); got != tt.dropped {
+				t.Errorf("dropped: %d, want %d", got, tt.dropped)
+			}
+		})
+	}
+}
+
+// drops are reported by the ticker without any later write
+func TestAsyncDroppedReport(t *testing.T) {
+	w := newGateWriter()
+	a := NewAsyncWriter(w, AsyncOptions{QueueSize: 1, Policy: AsyncDropNewest, ReportInterval: 20 * time.Millisecond})
+	a.WriteRecord(testRecord(INFO, "", "1"))
+	<-w.started
+	a.WriteRecord(testRecord(INFO, "", "2"))
+	a.WriteRecord(testRecord(INFO, "", "3"))
+	a.WriteRecord(testRecord(INFO, "", "4"))
+	close(w.gate)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !strings.Contains(strings.Join(w.written(), "\n"), "dropped=2") {
+		if time.Now().After(deadline) {
+			t.Fatalf("no report: %q", w.written())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	a.Close()
+}
+
+func TestAsyncClose(t *testing.T) {
+	var buf syncBuffer
+	a := NewAsyncWriter(&buf, AsyncOptions{Encoder: messageEncoder{}})
+	for i := 0; i < 100; i++ {
+		a.WriteRecord(testRecord(INFO, "", "m"))
+	}
+	if err := a.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(buf.String(), "m\n"); n != 100 {
+		t.Errorf("written: %d, want 100", n)
+	}
+	if err := a.WriteRecord(testRecord(INFO, "", "late")); err == nil {
+		t.Error("write after Close: no error")
+	}
+
+	// a stuck writer is given up on at the deadline
+	w := newGateWriter()
+	defer close(w.gate)
+	a = NewAsyncWriter(w, AsyncOptions{DrainTimeout: 50 * time.Millisecond})
+	a.WriteRecord(testRecord(INFO, "", "stuck"))
+	<-w.started
+	a.WriteRecord(testRecord(INFO, "", "queued"))
+	if err := a.Close(); err == nil {
+		t.Error("Close with a stuck writer: no error")
+	}
+}
+
+// records are encoded with the encoder of the logger writing them
+func TestAsyncLoggerEncoder(t *testing.T) {
+	var buf syncBuffer
+	a := NewAsyncWriter(&buf, AsyncOptions{})
+	l := NewStd2Logger3("info", "asy")
+	l.SetWriter(a)
+	l.SetEncoder(NewJSONEncoder())
+	l.Info("hello")
+	a.Close()
+	if !strings.HasPrefix(buf.String(), `{"time":`) || !strings.Contains(buf.String(), `"msg":"hello"`) {
+		t.Errorf("not json: %q", buf.String())
+	}
+}
+
+type syncBuffer struct {
+	mu sync.Mutex
+	b  bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.b.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.b.String()
+}
@@ -0,0 +1,236 @@
+// gologctl changes the log levels of a running process which called
+// gologging.ListenControl
+//
+//	gologctl -pid 1234 list
+//	gologctl -pid 1234 set 'db.*' trace
+//	gologctl -socket /run/app/logging.sock disable http.access
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	gologging "github.com/sigmonsays/go-logging"
+)
+
+const usage = `usage: gologctl [-socket path | -pid pid] command [args]
+
+commands:
+  list [pattern]          list loggers and their levels
+  show name               show the state of one logger
+  get name|pattern        print the level of matching loggers
+  set name|pattern level  set the level of matching loggers
+  disable name|pattern    disable matching loggers
+  enable name|pattern     enable matching loggers
+
+a name containing * ? or [ is matched as a glob pattern
+`
+
+func main() {
+	socket := flag.String("socket", "", "control socket path")
+	pid := flag.Int("pid", 0, "pid of the process, when -socket is not given")
+	flag.Usage = func() {
+		fmt.Fprint(os.Stderr, usage)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if *socket == "" {
+		if *pid == 0 {
+			fmt.Fprintln(os.Stderr, "gologctl: -socket or -pid is required")
+			os.Exit(2)
+		}
+		*socket = gologging.ControlSocketPath(*pid)
+	}
+	args := flag.Args()
+	if len(args) == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	c := newClient(*socket)
+	if err := run(c, args[0], args[1:]); err != nil {
+		fmt.Fprintf(os.Stderr, "gologctl: %s\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(c *client, cmd string, args []string) error {
+	want := func(n int) error {
+		if len(args) != n {
+			return fmt.Errorf("%s: expected %d arguments, got %d", cmd, n, len(args))
+		}
+		return nil
+	}
+	switch cmd {
+	case "list":
+		pattern := ""
+		if len(args) > 0 {
+			pattern = args[0]
+		}
+		states, err := c.list(selector(pattern))
+		if err != nil {
+			return err
+		}
+		printStates(states)
+	case "show":
+		if err := want(1); err != nil {
+			return err
+		}
+		states, err := c.list(url.Values{"name": {args[0]}})
+		if err != nil {
+			return err
+		}
+		if len(states) == 0 {
+			return fmt.Errorf("logger not found: %s", args[0])
+		}
+		s := states[0]
+		fmt.Printf("name:     %s\nlevel:    %s\nfrom:     %s\ndisabled: %t\n", s.Name, s.Level, levelSource(s), s.Disabled)
+	case "get":
+		if err := want(1); err != nil {
+			return err
+		}
+		states, err := c.list(selector(args[0]))
+		if err != nil {
+			return err
+		}
+		if len(states) == 0 {
+			return fmt.Errorf("no loggers matched: %s", args[0])
+		}
+		for _, s := range states {
+			fmt.Printf("%s %s\n", s.Name, s.Level)
+		}
+	case "set":
+		if err := want(2); err != nil {
+			return err
+		}
+		change := levelChange(args[0])
+		change.Level = args[1]
+		return c.change(change)
+	case "disable", "enable":
+		if err := want(1); err != nil {
+			return err
+		}
+		disable := cmd == "disable"
+		change := levelChange(args[0])
+		change.Disabled = &disable
+		return c.change(change)
+	default:
+		return fmt.Errorf("unknown command: %s", cmd)
+	}
+	return nil
+}
+
+func isPattern(s string) bool {
+	return strings.ContainsAny(s, "*?[")
+}
+
+func selector(s string) url.Values {
+	if s == "" {
+		return nil
+	}
+	if isPattern(s) {
+		return url.Values{"pattern": {s}}
+	}
+	return url.Values{"name": {s}}
+}
+
+func levelChange(s string) gologging.LevelChange {
+	if isPattern(s) {
+		return gologging.LevelChange{Pattern: s}
+	}
+	return gologging.LevelChange{Name: s}
+}
+
+func printStates(states []gologging.LoggerState) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tLEVEL\tFROM\tDISABLED")
+	for _, s := range states {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%t\n", s.Name, s.Level, levelSource(s), s.Disabled)
+	}
+	w.Flush()
+}
+
+// where the level of a logger comes from
+func levelSource(s gologging.LoggerState) string {
+	switch {
+	case s.Explicit != "":
+		return "explicit"
+	case s.InheritedFrom != "":
+		return s.InheritedFrom
+	}
+	return "default"
+}
+
+// talks http to the admin handler over the unix socket
+type client struct {
+	http *http.Client
+}
+
+func newClient(socket string) *client {
+	dial := func(ctx context.Context, network, addr string) (net.Conn, error) {
+		var d net.Dialer
+		return d.DialContext(ctx, "unix", socket)
+	}
+	return &client{http: &http.Client{Transport: &http.Transport{DialContext: dial}}}
+}
+
+func (c *client) list(query url.Values) ([]gologging.LoggerState, error) {
+	resp, err := c.http.Get("http://gologging/?" + query.Encode())
+	if err != nil {
+		return nil, err
+	}
+	var states []gologging.LoggerState
+	err = decode(resp, &states)
+	return states, err
+}
+
+func (c *client) change(change gologging.LevelChange) error {
+	body, err := json.Marshal(change)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPut, "http://gologging/", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	var states []gologging.LoggerState
+	if err := decode(resp, &states); err != nil {
+		return err
+	}
+	printStates(states)
+	return nil
+}
+
+func decode(resp *http.Response, v interface{}) error {
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		var e struct {
+			Error string `json:"error"`
+		}
+		if json.Unmarshal(body, &e) == nil && e.Error != "" {
+			return fmt.Errorf("%s", e.Error)
+		}
+		return fmt.Errorf("%s", resp.Status)
+	}
+	return json.Unmarshal(body, v)
+}
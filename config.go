@@ -0,0 +1,360 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Config describes the levels, format and outputs of the whole registry
+//
+//	{
+//	  "level": "info",
+//	  "format": "text",
+//	  "levels": {"db": "debug", "http.*": "trace", "cache": "off"},
+//	  "outputs": [
+//	    {"type": "stderr"},
+//	    {"type": "rotate", "path": "/var/log/app.log", "format": "json", "max_size": 104857600, "compress": true},
+//	    {"type": "syslog", "level": "error", "network": "udp", "address": "loghost:514"}
+//	  ]
+//	}
+type Config struct {
+	// level of loggers without an entry in Levels
+	Level string `json:"level,omitempty"`
+	// text, json or logfmt
+	Format string `json:"format,omitempty"`
+	// level by logger name or glob pattern, "off" disables the logger
+	Levels map[string]string `json:"levels,omitempty"`
+	// every output receives every record at or above its level
+	Outputs []OutputConfig `json:"outputs,omitempty"`
+}
+
+type OutputConfig struct {
+	// stderr, stdout, file, rotate, syslog or journald
+	Type string `json:"type"`
+	// only records at or above this level are written, defaults to every level
+	Level string `json:"level,omitempty"`
+	// defaults to the encoder of the logger, which is the Config format when one is set
+	Format string `json:"format,omitempty"`
+
+	// file and rotate
+	Path         string `json:"path,omitempty"`
+	MaxSize      int64  `json:"max_size,omitempty"`
+	MaxBackups   int    `json:"max_backups,omitempty"`
+	MaxAge       string `json:"max_age,omitempty"`
+	MaxTotalSize int64  `json:"max_total_size,omitempty"`
+	Compress     bool   `json:"compress,omitempty"`
+	// hourly or daily
+	Interval string `json:"interval,omitempty"`
+
+	// syslog
+	Network  string `json:"network,omitempty"`
+	Address  string `json:"address,omitempty"`
+	Facility string `json:"facility,omitempty"`
+	// rfc5424 or rfc3164
+	Protocol string `json:"protocol,omitempty"`
+	AppName  string `json:"app_name,omitempty"`
+
+	// journald
+	Socket     string `json:"socket,omitempty"`
+	Identifier string `json:"identifier,omitempty"`
+}
+
+var syslogFacilities = map[string]SyslogFacility{
+	"user":     FacilityUser,
+	"mail":     FacilityMail,
+	"daemon":   FacilityDaemon,
+	"auth":     FacilityAuth,
+	"syslog":   FacilitySyslog,
+	"lpr":      FacilityLpr,
+	"news":     FacilityNews,
+	"uucp":     FacilityUucp,
+	"cron":     FacilityCron,
+	"authpriv": FacilityAuthPriv,
+	"ftp":      FacilityFTP,
+	"local0":   FacilityLocal0,
+	"local1":   FacilityLocal1,
+	"local2":   FacilityLocal2,
+	"local3":   FacilityLocal3,
+	"local4":   FacilityLocal4,
+	"local5":   FacilityLocal5,
+	"local6":   FacilityLocal6,
+	"local7":   FacilityLocal7,
+}
+
+// parse a json config, unknown keys are an error
+func ParseConfig(data []byte) (*Config, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	c := &Config{}
+	if err := dec.Decode(c); err != nil {
+		return nil, fmt.Errorf("config: %s", err)
+	}
+	return c, nil
+}
+
+func LoadConfig(filename string) (*Config, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("config: %s", err)
+	}
+	c, err := ParseConfig(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", filename, err)
+	}
+	return c, nil
+}
+
+func checkLevel(level string, off bool) error {
+	if off && level == LevelOff {
+		return nil
+	}
+	if _, found := Constants[strings.ToUpper(level)]; !found {
+		return fmt.Errorf("invalid level %q", level)
+	}
+	return nil
+}
+
+func checkFormat(format string) error {
+	switch format {
+	case "", "text", "json", "logfmt":
+		return nil
+	}
+	return fmt.Errorf("invalid format %q, use text, json or logfmt", format)
+}
+
+// check every entry, all problems found are returned together
+func (c *Config) Validate() error {
+	var errs []error
+	if c.Level != "" {
+		if err := checkLevel(c.Level, false); err != nil {
+			errs = append(errs, fmt.Errorf("level: %s", err))
+		}
+	}
+	if err := checkFormat(c.Format); err != nil {
+		errs = append(errs, fmt.Errorf("format: %s", err))
+	}
+	names := make([]string, 0, len(c.Levels))
+	for name := range c.Levels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		level := c.Levels[name]
+		if _, err := path.Match(name, ""); err != nil {
+			errs = append(errs, fmt.Errorf("levels: invalid pattern %q: %s", name, err))
+		}
+		if err := checkLevel(level, true); err != nil {
+			errs = append(errs, fmt.Errorf("levels: %s: %s", name, err))
+		}
+	}
+	for i, o := range c.Outputs {
+		if err := o.validate(); err != nil {
+			errs = append(errs, fmt.Errorf("outputs[%d]: %s", i, err))
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("config: %s", err)
+	}
+	return nil
+}
+
+func (o *OutputConfig) validate() error {
+	var errs []error
+	if o.Level != "" {
+		if err := checkLevel(o.Level, false); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if err := checkFormat(o.Format); err != nil {
+		errs = append(errs, err)
+	}
+	switch o.Type {
+	case "stderr", "stdout", "journald":
+	case "file", "rotate":
+		if o.Path == "" {
+			errs = append(errs, fmt.Errorf("%s requires a path", o.Type))
+		}
+		if o.MaxAge != "" {
+			if _, err := time.ParseDuration(o.MaxAge); err != nil {
+				errs = append(errs, fmt.Errorf("max_age: %s", err))
+			}
+		}
+		switch o.Interval {
+		case "", "hourly", "daily":
+		default:
+			errs = append(errs, fmt.Errorf("invalid interval %q, use hourly or daily", o.Interval))
+		}
+	case "syslog":
+		if o.Facility != "" {
+			if _, found := syslogFacilities[o.Facility]; !found {
+				errs = append(errs, fmt.Errorf("invalid facility %q", o.Facility))
+			}
+		}
+		switch o.Protocol {
+		case "", "rfc5424", "rfc3164":
+		default:
+			errs = append(errs, fmt.Errorf("invalid protocol %q, use rfc5424 or rfc3164", o.Protocol))
+		}
+	case "":
+		errs = append(errs, fmt.Errorf("type is required"))
+	default:
+		errs = append(errs, fmt.Errorf("unknown type %q", o.Type))
+	}
+	return errors.Join(errs...)
+}
+
+// open the writer described by o, a validated config
+func (o *OutputConfig) open() (io.Writer, error) {
+	switch o.Type {
+	case "stdout":
+		return os.Stdout, nil
+	case "file":
+		return NewReopenableFile(o.Path, 0644)
+	case "rotate":
+		opts := RotateOptions{
+			MaxSize:      o.MaxSize,
+			Compress:     o.Compress,
+			MaxBackups:   o.MaxBackups,
+			MaxTotalSize: o.MaxTotalSize,
+		}
+		if o.MaxAge != "" {
+			opts.MaxAge, _ = time.ParseDuration(o.MaxAge)
+		}
+		switch o.Interval {
+		case "hourly":
+			opts.Interval = RotateHourly
+		case "daily":
+			opts.Interval = RotateDaily
+		}
+		return NewRotatingFile(o.Path, opts)
+	case "syslog":
+		opts := SyslogOptions{
+			Network:  o.Network,
+			Address:  o.Address,
+			Facility: syslogFacilities[o.Facility],
+			AppName:  o.AppName,
+		}
+		if o.Protocol == "rfc3164" {
+			opts.Format = RFC3164
+		}
+		return NewSyslogWriter(opts)
+	case "journald":
+		return NewJournalWriter(JournalOptions{Socket: o.Socket, Identifier: o.Identifier})
+	}
+	return os.Stderr, nil
+}
+
+// the config last applied, the outputs it opened and a copy of their config, as
+// the caller may change the config and apply it again
+var configMux sync.Mutex
+var currentConfig *Config
+var configOutput *FanOut
+var configOutputs []OutputConfig
+
+// CurrentConfig returns the config last applied with ApplyConfig, or nil
+func CurrentConfig() *Config {
+	outMux.RLock()
+	defer outMux.RUnlock()
+	return currentConfig
+}
+
+// ApplyConfig validates c and opens its outputs before changing anything, so an
+// invalid config or an output which fails to open leaves the registry as it was.
+// the levels replace any rules remembered before and also apply to loggers
+// registered later
+func ApplyConfig(c *Config) error {
+	configMux.Lock()
+	defer configMux.Unlock()
+	if err := c.Validate(); err != nil {
+		return err
+	}
+	var encoder Encoder
+	if c.Format != "" {
+		encoder, _ = NewEncoder(c.Format)
+	}
+	var out *FanOut
+	// outputs which did not change are kept open, eg. so a reload does not reconnect to syslog
+	reuse := configOutput != nil && reflect.DeepEqual(configOutputs, c.Outputs)
+	if reuse {
+		out = configOutput
+	} else if len(c.Outputs) > 0 {
+		sinks := []Sink{}
+		for i := range c.Outputs {
+			o := &c.Outputs[i]
+			w, err := o.open()
+			if err != nil {
+				NewFanOut(sinks...).Close()
+				return fmt.Errorf("config: outputs[%d]: %s", i, err)
+			}
+			s := Sink{Writer: w, Level: TRACE}
+			if o.Format != "" {
+				s.Encoder, _ = NewEncoder(o.Format)
+			}
+			if o.Level != "" {
+				s.Level = Constants[strings.ToUpper(o.Level)]
+			}
+			sinks = append(sinks, s)
+		}
+		out = NewFanOut(sinks...)
+	}
+
+	outMux.Lock()
+	levelRules = make(map[string]string, len(c.Levels))
+	for name, level := range c.Levels {
+		levelRules[name] = level
+	}
+	if c.Level != "" {
+		defaultLevel = c.Level
+	}
+	prev := configOutput
+	configOutput = out
+	configOutputs = append([]OutputConfig(nil), c.Outputs...)
+	currentConfig = c
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	outMux.Unlock()
+
+	for _, name := range names {
+		// without a config level loggers with no rule keep their level
+		if _, from := effectiveLevel(name); from == "" && c.Level == "" {
+			enableByRule(name)
+			continue
+		}
+		applyEffectiveLevel(name)
+	}
+
+	if c.Format != "" {
+		SetLogEncoder(encoder)
+	}
+	if out != nil {
+		SetLogOutput(out)
+	} else if prev != nil {
+		SetLogOutput(nil)
+	}
+	if prev != nil && !reuse {
+		prev.Flush()
+		prev.Close()
+	}
+	return nil
+}
+
+// load and apply a config file, see ApplyConfig
+func ApplyConfigFile(filename string) error {
+	c, err := LoadConfig(filename)
+	if err != nil {
+		return err
+	}
+	return ApplyConfig(c)
+}
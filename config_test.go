@@ -0,0 +1,140 @@
+package logging
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// put back the registry defaults changed by ApplyConfig
+func resetConfig(t *testing.T) {
+	t.Cleanup(func() {
+		ApplyConfig(&Config{})
+		SetLogLevel("trace")
+		SetLogEncoder(NewTextEncoder())
+	})
+}
+
+func levelOf(t *testing.T, name string) string {
+	t.Helper()
+	for _, s := range LoggerStates() {
+		if s.Name == name {
+			return s.Level
+		}
+	}
+	t.Fatalf("%s not registered", name)
+	return ""
+}
+
+func TestApplyConfig(t *testing.T) {
+	resetConfig(t)
+	dir := t.TempDir()
+	all, errs := filepath.Join(dir, "all.log"), filepath.Join(dir, "errors.log")
+	for _, name := range []string{"cfg.db", "cfg.http.api", "cfg.cache", "cfg.other"} {
+		Register(name, nil)
+	}
+	err := ApplyConfig(&Config{
+		Level:  "warning",
+		Format: "json",
+		Levels: map[string]string{"cfg.db": "debug", "cfg.http.*": "trace", "cfg.cache": "off"},
+		Outputs: []OutputConfig{
+			{Type: "file", Path: all},
+			{Type: "file", Path: errs, Level: "error", Format: "logfmt"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, want := range map[string]string{"cfg.db": "DEBUG", "cfg.http.api": "TRACE", "cfg.other": "WARNING"} {
+		if got := levelOf(t, name); got != want {
+			t.Errorf("%s: level %s, want %s", name, got, want)
+		}
+	}
+	if !IsDisabled("cfg.cache") {
+		t.Error("cfg.cache is not disabled")
+	}
+
+	GetLogger("cfg.db").Info("starting")
+	GetLogger("cfg.db").Error("failed")
+	GetLogger("cfg.other").Info("hidden")
+
+	got := readFile(t, all)
+	lines := strings.Split(strings.TrimSpace(got), "\n")
+	if len(lines) != 2 || !strings.Contains(lines[0], `"msg":"starting"`) || !strings.Contains(lines[1], `"msg":"failed"`) {
+		t.Errorf("%s: %q", all, got)
+	}
+	got = readFile(t, errs)
+	if strings.Count(got, "\n") != 1 || !strings.Contains(got, "msg=failed") {
+		t.Errorf("%s: %q", errs, got)
+	}
+
+	info, err := os.Stat(all)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm()&0600 != 0600 {
+		t.Errorf("file mode %s", info.Mode())
+	}
+}
+
+// an invalid config changes nothing
+func TestApplyConfigInvalid(t *testing.T) {
+	resetConfig(t)
+	valid := &Config{Level: "info"}
+	if err := ApplyConfig(valid); err != nil {
+		t.Fatal(err)
+	}
+	err := ApplyConfig(&Config{
+		Level:   "loud",
+		Levels:  map[string]string{"cfg[": "info"},
+		Outputs: []OutputConfig{{Type: "file"}},
+	})
+	if err == nil {
+		t.Fatal("no error")
+	}
+	for _, want := range []string{`level: invalid level "loud"`, `invalid pattern "cfg["`, "outputs[0]: file requires a path"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not mention %q", err, want)
+		}
+	}
+	if CurrentConfig() != valid {
+		t.Error("the current config was replaced")
+	}
+}
+
+// applying the same outputs again keeps them open, changed outputs are replaced
+// and the previous ones closed
+func TestApplyConfigKeepsOutputs(t *testing.T) {
+	resetConfig(t)
+	dir := t.TempDir()
+	outputs := []OutputConfig{{Type: "file", Path: filepath.Join(dir, "app.log")}}
+	if err := ApplyConfig(&Config{Level: "info", Outputs: outputs}); err != nil {
+		t.Fatal(err)
+	}
+	first := configOutput
+
+	if err := ApplyConfig(&Config{Level: "debug", Outputs: []OutputConfig{{Type: "file", Path: filepath.Join(dir, "app.log")}}}); err != nil {
+		t.Fatal(err)
+	}
+	if configOutput != first {
+		t.Fatal("unchanged outputs were opened again")
+	}
+	file := first.Sinks()[0].Writer.(*ReopenableFile)
+	if _, err := file.Write([]byte("still open\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	// changing the config applied before is noticed too
+	outputs[0].Path = filepath.Join(dir, "other.log")
+	if err := ApplyConfig(&Config{Outputs: outputs}); err != nil {
+		t.Fatal(err)
+	}
+	if configOutput == first {
+		t.Fatal("changed outputs were kept")
+	}
+	if _, err := file.Write([]byte("closed\n")); err != os.ErrClosed {
+		t.Errorf("previous output: %v, want it closed", err)
+	}
+}
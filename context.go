@@ -0,0 +1,179 @@
+package logging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+type contextKey int
+
+const (
+	loggerKey contextKey = iota
+	fieldsKey
+)
+
+// NewContext returns a copy of ctx carrying l
+func NewContext(ctx context.Context, l Logger) context.Context {
+	return context.WithValue(ctx, loggerKey, l)
+}
+
+// ContextWithFields returns a copy of ctx with keyvals added to the fields logged
+// by the Ctx functions, eg. a request or user id
+func ContextWithFields(ctx context.Context, keyvals ...interface{}) context.Context {
+	return context.WithValue(ctx, fieldsKey, appendFields(ContextFields(ctx), keyvals...))
+}
+
+// ContextFields returns the fields bound to ctx with ContextWithFields
+func ContextFields(ctx context.Context) []Field {
+	fields, _ := ctx.Value(fieldsKey).([]Field)
+	return fields
+}
+
+// FromContext returns the logger carried by ctx, or Std when there is none, with
+// the fields of ctx bound to it
+func FromContext(ctx context.Context) Logger {
+	return withContextFields(ctx, contextLogger(ctx, Std))
+}
+
+func contextLogger(ctx context.Context, fallback Logger) Logger {
+	if l, ok := ctx.Value(loggerKey).(Logger); ok && l != nil {
+		return l
+	}
+	return fallback
+}
+
+func withContextFields(ctx context.Context, l Logger) Logger {
+	fields := ContextFields(ctx)
+	if len(fields) == 0 {
+		return l
+	}
+	return l.With(fieldsToKeyvals(fields)...)
+}
+
+// log through the context logger, the caller is two frames above ctxLog
+func ctxLog(ctx context.Context, fallback Logger, level int, msg string) {
+	l := contextLogger(ctx, fallback)
+	if !isEnabled(l, level) {
+		return
+	}
+	logRecord(l, newRecord(3, level, "", msg, ContextFields(ctx)))
+}
+
+// ContextLogger logs through the logger carried by a context, falling back to the
+// registered logger Name when the context carries none
+//
+//	var ctxlog = gologging.NewContextLogger("whatever")
+//	ctxlog.InfofCtx(ctx, "handled %s", path)
+type ContextLogger struct {
+	Name string
+}
+
+func NewContextLogger(name string) *ContextLogger {
+	return &ContextLogger{Name: name}
+}
+
+func (c *ContextLogger) fallback() Logger {
+	if l := GetLogger(c.Name); l != nil {
+		return l
+	}
+	return Std
+}
+
+// Logger returns the logger used for ctx with the context fields bound to it
+func (c *ContextLogger) Logger(ctx context.Context) Logger {
+	return withContextFields(ctx, contextLogger(ctx, c.fallback()))
+}
+
+// print style functions
+func (c *ContextLogger) TraceCtx(ctx context.Context, args ...interface{}) {
+	ctxLog(ctx, c.fallback(), TRACE, fmt.Sprintln(args...))
+}
+func (c *ContextLogger) DebugCtx(ctx context.Context, args ...interface{}) {
+	ctxLog(ctx, c.fallback(), DEBUG, fmt.Sprintln(args...))
+}
+func (c *ContextLogger) InfoCtx(ctx context.Context, args ...interface{}) {
+	ctxLog(ctx, c.fallback(), INFO, fmt.Sprintln(args...))
+}
+func (c *ContextLogger) WarnCtx(ctx context.Context, args ...interface{}) error {
+	ctxLog(ctx, c.fallback(), WARNING, fmt.Sprintln(args...))
+	return errors.New(fmt.Sprintln(args...))
+}
+func (c *ContextLogger) ErrorCtx(ctx context.Context, args ...interface{}) error {
+	ctxLog(ctx, c.fallback(), ERROR, fmt.Sprintln(args...))
+	return errors.New(fmt.Sprintln(args...))
+}
+func (c *ContextLogger) CriticalCtx(ctx context.Context, args ...interface{}) error {
+	ctxLog(ctx, c.fallback(), CRITICAL, fmt.Sprintln(args...))
+	return errors.New(fmt.Sprintln(args...))
+}
+
+// printf style functions
+func (c *ContextLogger) TracefCtx(ctx context.Context, format string, args ...interface{}) {
+	ctxLog(ctx, c.fallback(), TRACE, fmt.Sprintf(format, args...))
+}
+func (c *ContextLogger) DebugfCtx(ctx context.Context, format string, args ...interface{}) {
+	ctxLog(ctx, c.fallback(), DEBUG, fmt.Sprintf(format, args...))
+}
+func (c *ContextLogger) InfofCtx(ctx context.Context, format string, args ...interface{}) {
+	ctxLog(ctx, c.fallback(), INFO, fmt.Sprintf(format, args...))
+}
+func (c *ContextLogger) WarnfCtx(ctx context.Context, format string, args ...interface{}) error {
+	ctxLog(ctx, c.fallback(), WARNING, fmt.Sprintf(format, args...))
+	return fmt.Errorf(format, args...)
+}
+func (c *ContextLogger) ErrorfCtx(ctx context.Context, format string, args ...interface{}) error {
+	ctxLog(ctx, c.fallback(), ERROR, fmt.Sprintf(format, args...))
+	return fmt.Errorf(format, args...)
+}
+func (c *ContextLogger) CriticalfCtx(ctx context.Context, format string, args ...interface{}) error {
+	ctxLog(ctx, c.fallback(), CRITICAL, fmt.Sprintf(format, args...))
+	return fmt.Errorf(format, args...)
+}
+
+// helper functions using the logger carried by ctx or Std
+// print style functions helper functions
+func TraceCtx(ctx context.Context, args ...interface{}) {
+	ctxLog(ctx, Std, TRACE, fmt.Sprintln(args...))
+}
+func DebugCtx(ctx context.Context, args ...interface{}) {
+	ctxLog(ctx, Std, DEBUG, fmt.Sprintln(args...))
+}
+func InfoCtx(ctx context.Context, args ...interface{}) {
+	ctxLog(ctx, Std, INFO, fmt.Sprintln(args...))
+}
+func WarnCtx(ctx context.Context, args ...interface{}) error {
+	ctxLog(ctx, Std, WARNING, fmt.Sprintln(args...))
+	return errors.New(fmt.Sprintln(args...))
+}
+func ErrorCtx(ctx context.Context, args ...interface{}) error {
+	ctxLog(ctx, Std, ERROR, fmt.Sprintln(args...))
+	return errors.New(fmt.Sprintln(args...))
+}
+func CriticalCtx(ctx context.Context, args ...interface{}) error {
+	ctxLog(ctx, Std, CRITICAL, fmt.Sprintln(args...))
+	return errors.New(fmt.Sprintln(args...))
+}
+
+// printf style functions helper functions
+func TracefCtx(ctx context.Context, format string, args ...interface{}) {
+	ctxLog(ctx, Std, TRACE, fmt.Sprintf(format, args...))
+}
+func DebugfCtx(ctx context.Context, format string, args ...interface{}) {
+	ctxLog(ctx, Std, DEBUG, fmt.Sprintf(format, args...))
+}
+func InfofCtx(ctx context.Context, format string, args ...interface{}) {
+	ctxLog(ctx, Std, INFO, fmt.Sprintf(format, args...))
+}
+func WarnfCtx(ctx context.Context, format string, args ...interface{}) error {
+	ctxLog(ctx, Std, WARNING, fmt.Sprintf(format, args...))
+	return fmt.Errorf(format, args...)
+}
+func ErrorfCtx(ctx context.Context, format string, args ...interface{}) error {
+	ctxLog(ctx, Std, ERROR, fmt.Sprintf(format, args...))
+	return fmt.Errorf(format, args...)
+}
+func CriticalfCtx(ctx context.Context, format string, args ...interface{}) error {
+	ctxLog(ctx, Std, CRITICAL, fmt.Sprintf(format, args...))
+	return fmt.Errorf(format, args...)
+}
@@ -0,0 +1,75 @@
+package logging
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
+// the control socket of process pid when ListenControl is given no path
+func ControlSocketPath(pid int) string {
+	return filepath.Join(os.TempDir(), fmt.Sprintf("gologging.%d.sock", pid))
+}
+
+// ListenControl serves the admin handler on a unix domain socket for gologctl.
+// an empty path uses ControlSocketPath(os.Getpid()). the socket is only accessible
+// by the owner of the process. close the returned io.Closer to stop serving
+//
+//	gologging.ListenControl("")
+//	gologctl -pid 1234 set db.pool trace
+func ListenControl(path string) (io.Closer, error) {
+	if path == "" {
+		path = ControlSocketPath(os.Getpid())
+	}
+	// a socket left behind by a process which did not exit cleanly
+	if info, err := os.Lstat(path); err == nil && info.Mode()&os.ModeSocket != 0 {
+		os.Remove(path)
+	}
+	ln, err := listenPrivate(path)
+	if err != nil {
+		return nil, fmt.Errorf("ListenControl: %s", err)
+	}
+	srv := &http.Server{Handler: NewAdminHandler()}
+	go srv.Serve(ln)
+	return &controlServer{Server: srv, path: path}, nil
+}
+
+// the socket is created in a directory only the owner can enter, made 0600 and
+// then moved to path, so nobody else can connect while it is more open
+func listenPrivate(path string) (net.Listener, error) {
+	dir, err := os.MkdirTemp(filepath.Dir(path), ".gologging.")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+	tmp := filepath.Join(dir, "sock")
+	ln, err := net.Listen("unix", tmp)
+	if err != nil {
+		return nil, err
+	}
+	// the socket is removed by controlServer.Close under its final name
+	ln.(*net.UnixListener).SetUnlinkOnClose(false)
+	if err := os.Chmod(tmp, 0600); err != nil {
+		ln.Close()
+		return nil, err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		ln.Close()
+		return nil, err
+	}
+	return ln, nil
+}
+
+type controlServer struct {
+	*http.Server
+	path string
+}
+
+func (s *controlServer) Close() error {
+	err := s.Server.Close()
+	os.Remove(s.path)
+	return err
+}
@@ -0,0 +1,187 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Encoder turns a Record into the bytes written to a loggers output
+type Encoder interface {
+	Encode(r *Record) []byte
+}
+
+// short level names used by the text layout
+var textLevels = map[int]string{
+	TRACE:    "TRACE",
+	DEBUG:    "DEBUG",
+	INFO:     "INFO",
+	WARNING:  "WARN",
+	ERROR:    "ERROR",
+	CRITICAL: "CRITICAL",
+}
+
+func levelName(level int) string {
+	if s, ok := Levels[level]; ok {
+		return strings.ToLower(s)
+	}
+	return strconv.Itoa(level)
+}
+
+// TextEncoder writes the default Std2Logger layout
+//
+//	name LEVEL file.go:NN: message key=value
+type TextEncoder struct{}
+
+func NewTextEncoder() *TextEncoder {
+	return &TextEncoder{}
+}
+
+func (e *TextEncoder) Encode(r *Record) []byte {
+	var b bytes.Buffer
+	b.WriteString(r.Name)
+	b.WriteByte(' ')
+	if s, ok := textLevels[r.Level]; ok {
+		b.WriteString(s)
+	} else {
+		b.WriteString(strconv.Itoa(r.Level))
+	}
+	b.WriteByte(' ')
+	b.WriteString(r.ShortFile())
+	b.WriteByte(':')
+	b.WriteString(strconv.Itoa(r.Line))
+	b.WriteString(": ")
+	b.WriteString(withFields(r.Message, r.Fields))
+	b.WriteByte('\n')
+	return b.Bytes()
+}
+
+// JSONEncoder writes one json object per line
+//
+//	{"time":"...","level":"info","logger":"db","caller":"file.go:NN","msg":"message","key":"value"}
+type JSONEncoder struct {
+	// defaults to time.RFC3339Nano
+	TimeFormat string
+}
+
+func NewJSONEncoder() *JSONEncoder {
+	return &JSONEncoder{}
+}
+
+func (e *JSONEncoder) Encode(r *Record) []byte {
+	format := e.TimeFormat
+	if format == "" {
+		format = time.RFC3339Nano
+	}
+	var b bytes.Buffer
+	b.WriteString(`{"time":`)
+	writeJSONString(&b, r.Time.Format(format))
+	b.WriteString(`,"level":`)
+	writeJSONString(&b, levelName(r.Level))
+	if r.Name != "" {
+		b.WriteString(`,"logger":`)
+		writeJSONString(&b, r.Name)
+	}
+	b.WriteString(`,"caller":`)
+	writeJSONString(&b, r.ShortFile()+":"+strconv.Itoa(r.Line))
+	b.WriteString(`,"msg":`)
+	writeJSONString(&b, r.Message)
+	for _, f := range r.Fields {
+		b.WriteByte(',')
+		writeJSONString(&b, f.Key)
+		b.WriteByte(':')
+		writeJSONValue(&b, f.Value)
+	}
+	b.WriteString("}\n")
+	return b.Bytes()
+}
+
+// LogfmtEncoder writes key=value pairs, quoting values containing spaces, quotes,
+// equal signs or control characters
+//
+//	ts=... level=info logger=db caller=file.go:NN msg="message text" key=value
+type LogfmtEncoder struct {
+	// defaults to time.RFC3339Nano
+	TimeFormat string
+}
+
+func NewLogfmtEncoder() *LogfmtEncoder {
+	return &LogfmtEncoder{}
+}
+
+func (e *LogfmtEncoder) Encode(r *Record) []byte {
+	format := e.TimeFormat
+	if format == "" {
+		format = time.RFC3339Nano
+	}
+	var b bytes.Buffer
+	writeLogfmtPair(&b, "ts", r.Time.Format(format))
+	writeLogfmtPair(&b, "level", levelName(r.Level))
+	if r.Name != "" {
+		writeLogfmtPair(&b, "logger", r.Name)
+	}
+	writeLogfmtPair(&b, "caller", r.ShortFile()+":"+strconv.Itoa(r.Line))
+	writeLogfmtPair(&b, "msg", r.Message)
+	for _, f := range r.Fields {
+		writeLogfmtPair(&b, f.Key, f.Value)
+	}
+	b.WriteByte('\n')
+	return b.Bytes()
+}
+
+func writeLogfmtPair(b *bytes.Buffer, key string, value interface{}) {
+	if b.Len() > 0 {
+		b.WriteByte(' ')
+	}
+	b.WriteString(logfmtKey(key))
+	b.WriteByte('=')
+	b.WriteString(formatValue(value))
+}
+
+// keys cannot be quoted in logfmt so anything that would break parsing is replaced
+func logfmtKey(key string) string {
+	if key == "" {
+		return "_"
+	}
+	return strings.Map(func(r rune) rune {
+		if r <= ' ' || r == '=' || r == '"' || r == 0x7f {
+			return '_'
+		}
+		return r
+	}, key)
+}
+
+// NewEncoder returns the encoder for a format name: text, json or logfmt
+func NewEncoder(format string) (Encoder, error) {
+	switch strings.ToLower(format) {
+	case "text", "":
+		return NewTextEncoder(), nil
+	case "json":
+		return NewJSONEncoder(), nil
+	case "logfmt":
+		return NewLogfmtEncoder(), nil
+	}
+	return nil, fmt.Errorf("Invalid format: %s, valid formats text, json, logfmt", format)
+}
+
+func writeJSONString(b *bytes.Buffer, s string) {
+	buf, _ := json.Marshal(s)
+	b.Write(buf)
+}
+
+// errors and values json cannot marshal are written as strings
+func writeJSONValue(b *bytes.Buffer, v interface{}) {
+	if err, ok := v.(error); ok {
+		writeJSONString(b, err.Error())
+		return
+	}
+	buf, err := json.Marshal(v)
+	if err != nil {
+		writeJSONString(b, fmt.Sprint(v))
+		return
+	}
+	b.Write(buf)
+}
@@ -0,0 +1,91 @@
+package logging
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+)
+
+// environment variable read at startup, a level spec as ParseLevelSpec
+//
+//	GOLOGGING="info,db=debug,http.*=trace,cache=off"
+const EnvLevels = "GOLOGGING"
+
+// parse a comma separated level spec. an entry without a name sets the default
+// level, name=level sets a logger or glob pattern, level may be "off".
+// the valid entries are returned along with an error listing the malformed ones
+func ParseLevelSpec(spec string) (level string, levels map[string]string, err error) {
+	levels = make(map[string]string)
+	var errs []error
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, lvl, found := strings.Cut(entry, "=")
+		if !found {
+			if err := checkLevel(entry, false); err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			level = entry
+			continue
+		}
+		name = strings.TrimSpace(name)
+		lvl = strings.TrimSpace(lvl)
+		if name == "" {
+			errs = append(errs, fmt.Errorf("missing logger name in %q", entry))
+			continue
+		}
+		if _, err := path.Match(name, ""); err != nil {
+			errs = append(errs, fmt.Errorf("invalid pattern %q: %s", name, err))
+			continue
+		}
+		if err := checkLevel(lvl, true); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %s", name, err))
+			continue
+		}
+		levels[name] = lvl
+	}
+	if len(errs) > 0 {
+		msgs := make([]string, len(errs))
+		for i, e := range errs {
+			msgs[i] = e.Error()
+		}
+		err = errors.New(strings.Join(msgs, ", "))
+	}
+	return level, levels, err
+}
+
+// apply a level spec to the registry and remember it for loggers registered later.
+// malformed entries are skipped and returned as the error
+func SetLevelSpec(spec string) error {
+	level, levels, err := ParseLevelSpec(spec)
+	if level != "" {
+		SetDefaultLevel(level)
+	}
+	for pattern, lvl := range levels {
+		SetLevelRule(pattern, lvl)
+	}
+	for name := range ListLogger() {
+		applyEffectiveLevel(name)
+	}
+	if err != nil {
+		return fmt.Errorf("SetLevelSpec: %s", err)
+	}
+	return nil
+}
+
+// apply the GOLOGGING environment variable, done at startup
+func ApplyEnv() error {
+	spec := os.Getenv(EnvLevels)
+	if spec == "" {
+		return nil
+	}
+	if err := SetLevelSpec(spec); err != nil {
+		return fmt.Errorf("%s: %s", EnvLevels, err)
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package logging
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseLevelSpec(t *testing.T) {
+	tests := []struct {
+		spec   string
+		level  string
+		levels map[string]string
+		err    string
+	}{
+		{"", "", map[string]string{}, ""},
+		{"info", "info", map[string]string{}, ""},
+		{"db=debug", "", map[string]string{"db": "debug"}, ""},
+		{
+			" warning , db = debug,http.*=trace,cache=off,",
+			"warning",
+			map[string]string{"db": "debug", "http.*": "trace", "cache": "off"},
+			"",
+		},
+		// the last default wins
+		{"info,error", "error", map[string]string{}, ""},
+		// malformed entries are reported and the others kept
+		{"loud,db=debug", "", map[string]string{"db": "debug"}, `invalid level "loud"`},
+		{"off", "", map[string]string{}, `invalid level "off"`},
+		{"=debug,db=info", "", map[string]string{"db": "info"}, `missing logger name in "=debug"`},
+		{"db[=debug", "", map[string]string{}, `invalid pattern "db["`},
+		{"db=loud,http=trace", "", map[string]string{"http": "trace"}, `db: invalid level "loud"`},
+	}
+	for _, tt := range tests {
+		level, levels, err := ParseLevelSpec(tt.spec)
+		if level != tt.level || !reflect.DeepEqual(levels, tt.levels) {
+			t.Errorf("%q: got %q %v, want %q %v", tt.spec, level, levels, tt.level, tt.levels)
+		}
+		if tt.err == "" && err != nil {
+			t.Errorf("%q: %s", tt.spec, err)
+		}
+		if tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)) {
+			t.Errorf("%q: error %v, want %s", tt.spec, err, tt.err)
+		}
+	}
+}
+
+// a spec applies to registered loggers and those registered later
+func TestSetLevelSpec(t *testing.T) {
+	defer SetLogLevel("trace")
+	Register("spec.db", nil)
+	Register("spec.http", nil)
+
+	err := SetLevelSpec("error,spec.db=debug,spec.http*=info,spec.cache=loud")
+	if err == nil || !strings.Contains(err.Error(), "spec.cache") {
+		t.Errorf("error %v, want the malformed entry", err)
+	}
+	Register("spec.http.api", nil)
+	Register("spec.other", nil)
+
+	for name, want := range map[string]string{
+		"spec.db":       "DEBUG",
+		"spec.http":     "INFO",
+		"spec.http.api": "INFO",
+		"spec.other":    "ERROR",
+	} {
+		if got := levelOf(t, name); got != want {
+			t.Errorf("%s: level %s, want %s", name, got, want)
+		}
+	}
+}
+
+func TestApplyEnv(t *testing.T) {
+	defer SetLogLevel("trace")
+	Register("envlog", nil)
+	t.Setenv(EnvLevels, "envlog=warning")
+	if err := ApplyEnv(); err != nil {
+		t.Fatal(err)
+	}
+	if got := levelOf(t, "envlog"); got != "WARNING" {
+		t.Errorf("level %s, want WARNING", got)
+	}
+	t.Setenv(EnvLevels, "envlog=")
+	if err := ApplyEnv(); err == nil || !strings.HasPrefix(err.Error(), EnvLevels+": ") {
+		t.Errorf("error %v", err)
+	}
+}
@@ -0,0 +1,195 @@
+package logging
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Sink is one destination of a FanOut with its own format and minimum level
+type Sink struct {
+	Writer io.Writer
+	// defaults to the encoder of the logger writing the record, or a TextEncoder.
+	// not used when Writer is a RecordWriter
+	Encoder Encoder
+	// records below Level are not written to this sink
+	Level int
+	// write the sink from its own goroutine through an AsyncWriter with these
+	// options, so a slow or stalled sink does not hold up the others. nil writes it
+	// on the goroutine logging the record
+	Async *AsyncOptions
+}
+
+// a failing sink is skipped for a while so it does not slow down the others,
+// the wait grows with each failure in a row up to maxSinkBackoff
+const sinkBackoff = time.Second
+const maxSinkBackoff = 30 * time.Second
+
+// FanOut writes each record to every sink at or below the records level. an
+// error or panic from one sink does not stop the others, and a sink which keeps
+// failing is skipped for a growing interval until it works again. sinks are
+// written in turn on the logging goroutine unless they set Async
+//
+//	f, err := gologging.NewRotatingFile("/var/log/app.log", gologging.RotateOptions{})
+//	log.SetWriter(gologging.NewFanOut(
+//		gologging.Sink{Writer: os.Stderr, Level: gologging.DEBUG},
+//		gologging.Sink{Writer: f, Encoder: gologging.NewJSONEncoder(), Level: gologging.WARNING},
+//		gologging.Sink{Writer: syslog, Async: &gologging.AsyncOptions{Policy: gologging.AsyncDropOldest}},
+//	))
+type FanOut struct {
+	sinks []*fanOutSink
+}
+
+type fanOutSink struct {
+	Sink
+	// what is written, an AsyncWriter around Writer for an async sink
+	w        io.Writer
+	text     Encoder
+	mu       sync.Mutex
+	failures int
+	retry    time.Time
+	// records not written while the sink was skipped
+	skipped atomic.Uint64
+}
+
+// async sinks each start a goroutine, stopped by Close
+func NewFanOut(sinks ...Sink) *FanOut {
+	f := &FanOut{}
+	for _, s := range sinks {
+		fs := &fanOutSink{Sink: s, w: s.Writer, text: NewTextEncoder()}
+		if s.Async != nil {
+			opts := *s.Async
+			if opts.Encoder == nil {
+				opts.Encoder = s.Encoder
+			}
+			fs.w = NewAsyncWriter(s.Writer, opts)
+		}
+		f.sinks = append(f.sinks, fs)
+	}
+	return f
+}
+
+func (f *FanOut) Sinks() []Sink {
+	sinks := make([]Sink, len(f.sinks))
+	for i, s := range f.sinks {
+		sinks[i] = s.Sink
+	}
+	return sinks
+}
+
+// the number of records not written because a sink was skipped after failing, or
+// dropped by the queue of an async sink
+func (f *FanOut) Dropped() uint64 {
+	var dropped uint64
+	for _, s := range f.sinks {
+		dropped += s.skipped.Load()
+		if a, ok := s.w.(*AsyncWriter); ok {
+			dropped += a.Dropped()
+		}
+	}
+	return dropped
+}
+
+func (f *FanOut) WriteRecord(r *Record) error {
+	return f.writeRecordWith(r, nil)
+}
+
+// encoder is the one of the logger, for sinks without their own
+func (f *FanOut) writeRecordWith(r *Record, encoder Encoder) error {
+	var errs []error
+	for _, s := range f.sinks {
+		if r.Level < s.Level {
+			continue
+		}
+		errs = append(errs, s.write(func() error {
+			enc := s.Encoder
+			if enc == nil {
+				enc = encoder
+			}
+			if rw, ok := s.w.(RecordWriter); ok {
+				return writeRecordTo(rw, r, enc)
+			}
+			if enc == nil {
+				enc = s.text
+			}
+			_, err := s.w.Write(enc.Encode(r))
+			return err
+		}))
+	}
+	return errors.Join(errs...)
+}
+
+// preformatted lines, eg. from the log package, go to every sink
+func (f *FanOut) Write(p []byte) (int, error) {
+	var errs []error
+	for _, s := range f.sinks {
+		errs = append(errs, s.write(func() error {
+			_, err := s.w.Write(p)
+			return err
+		}))
+	}
+	return len(p), errors.Join(errs...)
+}
+
+func (s *fanOutSink) write(fn func() error) (err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.failures > 0 && time.Now().Before(s.retry) {
+		s.skipped.Add(1)
+		return nil
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("FanOut: sink panic: %v", p)
+		}
+		if err == nil {
+			s.failures = 0
+			return
+		}
+		s.failures++
+		backoff := time.Duration(s.failures) * sinkBackoff
+		if backoff > maxSinkBackoff {
+			backoff = maxSinkBackoff
+		}
+		s.retry = time.Now().Add(backoff)
+	}()
+	return fn()
+}
+
+// async sinks wait for their queue to be written first
+func (f *FanOut) Flush() error {
+	var errs []error
+	for _, s := range f.sinks {
+		if fl, ok := s.w.(flusher); ok {
+			s.mu.Lock()
+			errs = append(errs, fl.Flush())
+			s.mu.Unlock()
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// close every sink, stdout and stderr are left open. async sinks write their queue
+// first and stop their goroutine
+func (f *FanOut) Close() error {
+	var errs []error
+	for _, s := range f.sinks {
+		if a, ok := s.w.(*AsyncWriter); ok {
+			errs = append(errs, a.Close())
+			continue
+		}
+		if s.Writer == os.Stdout || s.Writer == os.Stderr {
+			continue
+		}
+		if c, ok := s.Writer.(io.Closer); ok {
+			s.mu.Lock()
+			errs = append(errs, c.Close())
+			s.mu.Unlock()
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,158 @@
+package logging
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// a syncBuffer which fails while err is set, records every Close
+type sinkWriter struct {
+	syncBuffer
+	err    error
+	calls  int
+	closed bool
+}
+
+func (w *sinkWriter) Write(p []byte) (int, error) {
+	w.calls++
+	if w.err != nil {
+		return 0, w.err
+	}
+	return w.syncBuffer.Write(p)
+}
+
+func (w *sinkWriter) Close() error {
+	w.closed = true
+	return nil
+}
+
+type panicWriter struct{}
+
+func (panicWriter) Write(p []byte) (int, error) {
+	panic("broken sink")
+}
+
+func TestFanOutLevel(t *testing.T) {
+	var debug, warning syncBuffer
+	f := NewFanOut(
+		Sink{Writer: &debug, Encoder: messageEncoder{}, Level: DEBUG},
+		Sink{Writer: &warning, Encoder: messageEncoder{}, Level: WARNING},
+	)
+	for _, r := range []*Record{
+		testRecord(TRACE, "", "trace"),
+		testRecord(INFO, "", "info"),
+		testRecord(WARNING, "", "warning"),
+		testRecord(ERROR, "", "error"),
+	} {
+		if err := f.WriteRecord(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got, want := debug.String(), "info\nwarning\nerror\n"; got != want {
+		t.Errorf("DEBUG sink: got %q, want %q", got, want)
+	}
+	if got, want := warning.String(), "warning\nerror\n"; got != want {
+		t.Errorf("WARNING sink: got %q, want %q", got, want)
+	}
+}
+
+func TestFanOutPanic(t *testing.T) {
+	var buf syncBuffer
+	f := NewFanOut(
+		Sink{Writer: panicWriter{}, Encoder: messageEncoder{}},
+		Sink{Writer: &buf, Encoder: messageEncoder{}},
+	)
+	err := f.WriteRecord(testRecord(INFO, "", "hello"))
+	if err == nil || !strings.Contains(err.Error(), "broken sink") {
+		t.Errorf("got error %v, want the panic", err)
+	}
+	if buf.String() != "hello\n" {
+		t.Errorf("other sink got %q", buf.String())
+	}
+}
+
+func TestFanOutBackoff(t *testing.T) {
+	w := &sinkWriter{err: errors.New("disk full")}
+	f := NewFanOut(Sink{Writer: w, Encoder: messageEncoder{}})
+
+	if err := f.WriteRecord(testRecord(INFO, "", "1")); err == nil {
+		t.Fatal("failing sink: no error")
+	}
+	// skipped while backing off
+	if err := f.WriteRecord(testRecord(INFO, "", "2")); err != nil {
+		t.Fatal(err)
+	}
+	if w.calls != 1 {
+		t.Errorf("written %d times while backing off", w.calls)
+	}
+	if f.Dropped() != 1 {
+		t.Errorf("Dropped() = %d, want 1", f.Dropped())
+	}
+
+	// the sink is tried again once the backoff has passed
+	w.err = nil
+	f.sinks[0].retry = time.Now().Add(-time.Second)
+	if err := f.WriteRecord(testRecord(INFO, "", "3")); err != nil {
+		t.Fatal(err)
+	}
+	if f.WriteRecord(testRecord(INFO, "", "4")); w.String() != "3\n4\n" {
+		t.Errorf("after backoff got %q", w.String())
+	}
+}
+
+func TestFanOutClose(t *testing.T) {
+	w := &sinkWriter{}
+	queued := &sinkWriter{}
+	f := NewFanOut(
+		Sink{Writer: w, Encoder: messageEncoder{}},
+		Sink{Writer: queued, Encoder: messageEncoder{}, Async: &AsyncOptions{}},
+	)
+	for _, msg := range []string{"1", "2", "3"} {
+		f.WriteRecord(testRecord(INFO, "", msg))
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if !w.closed || !queued.closed {
+		t.Errorf("closed: %v, async sink closed: %v", w.closed, queued.closed)
+	}
+	if queued.String() != "1\n2\n3\n" {
+		t.Errorf("async sink not drained: %q", queued.String())
+	}
+}
+
+// a stalled async sink does not hold up the others
+func TestFanOutAsyncStalled(t *testing.T) {
+	var buf syncBuffer
+	stalled := newGateWriter()
+	f := NewFanOut(
+		Sink{Writer: stalled, Encoder: messageEncoder{}, Async: &AsyncOptions{ReportInterval: time.Hour}},
+		Sink{Writer: &buf, Encoder: messageEncoder{}},
+	)
+	for _, msg := range []string{"1", "2", "3"} {
+		f.WriteRecord(testRecord(INFO, "", msg))
+	}
+	<-stalled.started
+	if buf.String() != "1\n2\n3\n" {
+		t.Errorf("other sink got %q", buf.String())
+	}
+	close(stalled.gate)
+	f.Close()
+	if got := strings.Join(stalled.written(), ","); got != "1,2,3" {
+		t.Errorf("async sink got %q", got)
+	}
+}
+
+// sinks without an encoder use the one of the logger
+func TestFanOutLoggerEncoder(t *testing.T) {
+	var buf syncBuffer
+	l := NewStd2Logger3("info", "fan")
+	l.SetWriter(NewFanOut(Sink{Writer: &buf}))
+	l.SetEncoder(NewJSONEncoder())
+	l.Info("hello")
+	if !strings.HasPrefix(buf.String(), `{"time":`) || !strings.Contains(buf.String(), `"msg":"hello"`) {
+		t.Errorf("not json: %q", buf.String())
+	}
+}
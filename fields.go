@@ -0,0 +1,115 @@
+package logging
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Field is a key/value pair bound to a logger with With or WithFields
+type Field struct {
+	Key   string
+	Value interface{}
+}
+
+// build fields from alternating key, value arguments. a Field may also be passed
+// in place of a key. a trailing key without a value is kept with a nil value
+func makeFields(keyvals ...interface{}) []Field {
+	fields := make([]Field, 0, (len(keyvals)+1)/2)
+	for i := 0; i < len(keyvals); i++ {
+		if f, ok := keyvals[i].(Field); ok {
+			fields = append(fields, f)
+			continue
+		}
+		var key string
+		if s, ok := keyvals[i].(string); ok {
+			key = s
+		} else {
+			key = fmt.Sprint(keyvals[i])
+		}
+		var value interface{}
+		if i+1 < len(keyvals) {
+			i++
+			value = keyvals[i]
+		}
+		fields = append(fields, Field{Key: key, Value: value})
+	}
+	return fields
+}
+
+// convert a map into keyvals sorted by key so output order is stable
+func mapToKeyvals(m map[string]interface{}) []interface{} {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	keyvals := make([]interface{}, 0, len(m)*2)
+	for _, k := range keys {
+		keyvals = append(keyvals, k, m[k])
+	}
+	return keyvals
+}
+
+func fieldsToKeyvals(fields []Field) []interface{} {
+	keyvals := make([]interface{}, len(fields))
+	for i, f := range fields {
+		keyvals[i] = f
+	}
+	return keyvals
+}
+
+// appendFields returns a new slice so children never share backing arrays with their parent
+func appendFields(parent []Field, keyvals ...interface{}) []Field {
+	child := makeFields(keyvals...)
+	fields := make([]Field, 0, len(parent)+len(child))
+	fields = append(fields, parent...)
+	fields = append(fields, child...)
+	return fields
+}
+
+func needsQuoting(s string) bool {
+	if s == "" {
+		return true
+	}
+	for _, r := range s {
+		if r <= ' ' || r == '=' || r == '"' || r == 0x7f {
+			return true
+		}
+	}
+	return false
+}
+
+func formatValue(v interface{}) string {
+	var s string
+	switch t := v.(type) {
+	case string:
+		s = t
+	default:
+		s = fmt.Sprint(v)
+	}
+	if needsQuoting(s) {
+		return fmt.Sprintf("%q", s)
+	}
+	return s
+}
+
+// appends " key=value" for each field onto msg, keeping a trailing newline at the end
+func withFields(msg string, fields []Field) string {
+	if len(fields) == 0 {
+		return msg
+	}
+	var b strings.Builder
+	nl := strings.HasSuffix(msg, "\n")
+	b.WriteString(strings.TrimSuffix(msg, "\n"))
+	for _, f := range fields {
+		b.WriteByte(' ')
+		b.WriteString(f.Key)
+		b.WriteByte('=')
+		b.WriteString(formatValue(f.Value))
+	}
+	if nl {
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
@@ -0,0 +1,96 @@
+package logging
+
+import (
+	"flag"
+	"io"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Level is a log level usable as a flag.Value
+//
+//	level := gologging.Level(gologging.INFO)
+//	flag.Var(&level, "level", "log level")
+type Level int
+
+func (l Level) String() string {
+	if s, ok := Levels[int(l)]; ok {
+		return strings.ToLower(s)
+	}
+	return levelName(int(l))
+}
+
+func (l *Level) Set(s string) error {
+	if err := checkLevel(s, false); err != nil {
+		return err
+	}
+	*l = Level(Constants[strings.ToUpper(s)])
+	return nil
+}
+
+// RegisterFlags adds flags which configure the registry as they are parsed, a nil
+// fs uses flag.CommandLine
+//
+//	-log-level info
+//	-log-levels db=debug,http.*=trace,cache=off
+//	-log-format json
+//	-log-output stderr|stdout|syslog|journald|path
+func RegisterFlags(fs *flag.FlagSet) {
+	if fs == nil {
+		fs = flag.CommandLine
+	}
+	fs.Func("log-level", "default log level (trace, debug, info, warning, error, critical)", func(s string) error {
+		var level Level
+		if err := level.Set(s); err != nil {
+			return err
+		}
+		SetDefaultLevel(s)
+		return nil
+	})
+	fs.Func("log-levels", "comma separated logger levels, name=level or pattern=level, level may be off", func(s string) error {
+		_, _, err := ParseLevelSpec(s)
+		if err != nil {
+			return err
+		}
+		return SetLevelSpec(s)
+	})
+	fs.Func("log-format", "log format (text, json, logfmt)", SetLogFormat)
+	fs.Func("log-output", "log output (stderr, stdout, syslog, journald or a file path)", func(s string) error {
+		o := OutputConfig{Type: s}
+		switch s {
+		case "stderr", "stdout", "syslog", "journald":
+		default:
+			o = OutputConfig{Type: "file", Path: s}
+		}
+		w, err := o.open()
+		if err != nil {
+			return err
+		}
+		if err := SetLogOutput(w); err != nil {
+			return err
+		}
+		setFlagOutput(w)
+		return nil
+	})
+}
+
+// the writer opened by the last -log-output, closed when another replaces it
+var flagOutputMux sync.Mutex
+var flagOutput io.Writer
+
+func setFlagOutput(w io.Writer) {
+	flagOutputMux.Lock()
+	prev := flagOutput
+	flagOutput = w
+	flagOutputMux.Unlock()
+	if prev == nil || prev == os.Stdout || prev == os.Stderr {
+		return
+	}
+	if f, ok := prev.(flusher); ok {
+		f.Flush()
+	}
+	if c, ok := prev.(io.Closer); ok {
+		c.Close()
+	}
+}
@@ -0,0 +1,90 @@
+package logging
+
+import (
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLevelFlag(t *testing.T) {
+	level := Level(INFO)
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fs.Var(&level, "level", "")
+	if err := fs.Parse([]string{"-level", "Warning"}); err != nil {
+		t.Fatal(err)
+	}
+	if level != Level(WARNING) || level.String() != "warning" {
+		t.Errorf("got %d %s", level, level)
+	}
+	if err := fs.Parse([]string{"-level", "loud"}); err == nil {
+		t.Error("invalid level: no error")
+	}
+}
+
+func TestRegisterFlags(t *testing.T) {
+	t.Cleanup(func() {
+		SetLogOutput(nil)
+		setFlagOutput(nil)
+		SetLogLevel("trace")
+		SetLogEncoder(NewTextEncoder())
+	})
+	dir := t.TempDir()
+	first, second := filepath.Join(dir, "first.log"), filepath.Join(dir, "second.log")
+	Register("flg.db", nil)
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	RegisterFlags(fs)
+	err := fs.Parse([]string{
+		"-log-level", "error",
+		"-log-levels", "flg.db=debug,flg.http*=info",
+		"-log-format", "json",
+		"-log-output", first,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	Register("flg.http", nil)
+	Register("flg.other", nil)
+	for name, want := range map[string]string{"flg.db": "DEBUG", "flg.http": "INFO", "flg.other": "ERROR"} {
+		if got := levelOf(t, name); got != want {
+			t.Errorf("%s: level %s, want %s", name, got, want)
+		}
+	}
+
+	GetLogger("flg.db").Debug("to first")
+	if got := readFile(t, first); !strings.HasPrefix(got, "{") || !strings.Contains(got, `"msg":"to first"`) {
+		t.Errorf("first output: %q", got)
+	}
+
+	// another -log-output closes the file opened before
+	opened := flagOutput.(*ReopenableFile)
+	if err := fs.Parse([]string{"-log-output", second}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := opened.Write([]byte("x\n")); err != os.ErrClosed {
+		t.Errorf("first output: %v, want it closed", err)
+	}
+	GetLogger("flg.db").Debug("to second")
+	if got := readFile(t, second); !strings.Contains(got, `"msg":"to second"`) {
+		t.Errorf("second output: %q", got)
+	}
+
+	for _, args := range [][]string{
+		{"-log-level", "loud"},
+		{"-log-levels", "flg.db=loud"},
+		{"-log-format", "xml"},
+		{"-log-output", filepath.Join(dir, "missing", "app.log")},
+	} {
+		if err := fs.Parse(args); err == nil {
+			t.Errorf("%v: no error", args)
+		}
+	}
+	if got := levelOf(t, "flg.db"); got != "DEBUG" {
+		t.Errorf("flg.db changed by a malformed flag: %s", got)
+	}
+}
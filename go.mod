@@ -1,3 +1,3 @@
 module github.com/sigmonsays/go-logging
 
-go 1.17
+go 1.21
@@ -0,0 +1,174 @@
+package logging
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sync/atomic"
+)
+
+// Handle is the Logger returned by Register. it writes through whichever logger is
+// currently registered under its name, so ReplaceLogger, DisableLog and EnableLog
+// are seen by every holder without a ReplaceFunction
+//
+//	var log = gologging.Register("db", nil)
+type Handle struct {
+	name string
+	// the handle kept for name, itself unless this is a child made by With
+	root   *Handle
+	fields []Field
+	log    atomic.Pointer[handleRef]
+}
+
+// atomic.Pointer needs a concrete type to point at
+type handleRef struct {
+	Logger
+}
+
+// handles by name, updated by ReplaceLogger
+var handles = make(map[string]*Handle)
+
+// the handle for name, created pointing at log. guarded by outMux
+func handleFor(name string, log Logger) *Handle {
+	if h, found := handles[name]; found {
+		return h
+	}
+	h := &Handle{name: name}
+	h.root = h
+	h.set(log)
+	handles[name] = h
+	return h
+}
+
+func (h *Handle) set(log Logger) {
+	h.log.Store(&handleRef{log})
+}
+
+func (h *Handle) Name() string {
+	return h.name
+}
+
+// the logger currently registered under the handles name, without the fields of With
+func (h *Handle) Logger() Logger {
+	return h.root.log.Load().Logger
+}
+
+// the caller is taken here so it is the code using the handle, not the handle
+func (h *Handle) output(level int, msg string) {
+	l := h.Logger()
+	if !isEnabled(l, level) {
+		return
+	}
+	// skip output and the Handle method calling it
+	logRecord(l, newRecord(3, level, "", msg, h.fields))
+}
+
+func (h *Handle) Log(r *Record) error {
+	if len(h.fields) > 0 {
+		r.Fields = appendFields(h.fields, fieldsToKeyvals(r.Fields)...)
+	}
+	return logRecord(h.Logger(), r)
+}
+
+func (h *Handle) GetLevel() string {
+	return h.Logger().GetLevel()
+}
+func (h *Handle) SetLevel(level string) error {
+	return h.Logger().SetLevel(level)
+}
+func (h *Handle) SetWriter(w io.Writer) {
+	h.Logger().SetWriter(w)
+}
+func (h *Handle) SetEncoder(encoder Encoder) {
+	h.Logger().SetEncoder(encoder)
+}
+
+// the child is a handle too, so it follows level changes, DisableLog and
+// ReplaceLogger of the name like its parent
+func (h *Handle) With(keyvals ...interface{}) Logger {
+	return &Handle{name: h.name, root: h.root, fields: appendFields(h.fields, keyvals...)}
+}
+func (h *Handle) WithFields(fields map[string]interface{}) Logger {
+	return h.With(mapToKeyvals(fields)...)
+}
+
+// print style functions
+func (h *Handle) Trace(args ...interface{}) {
+	h.output(TRACE, fmt.Sprintln(args...))
+}
+func (h *Handle) Debug(args ...interface{}) {
+	h.output(DEBUG, fmt.Sprintln(args...))
+}
+func (h *Handle) Info(args ...interface{}) {
+	h.output(INFO, fmt.Sprintln(args...))
+}
+func (h *Handle) Warn(args ...interface{}) error {
+	msg := fmt.Sprintln(args...)
+	h.output(WARNING, msg)
+	return errors.New(msg)
+}
+func (h *Handle) Error(args ...interface{}) error {
+	msg := fmt.Sprintln(args...)
+	h.output(ERROR, msg)
+	return errors.New(msg)
+}
+func (h *Handle) Critical(args ...interface{}) error {
+	msg := fmt.Sprintln(args...)
+	h.output(CRITICAL, msg)
+	return errors.New(msg)
+}
+
+// printf style functions
+func (h *Handle) Tracef(format string, args ...interface{}) {
+	h.output(TRACE, fmt.Sprintf(format, args...))
+}
+func (h *Handle) Debugf(format string, args ...interface{}) {
+	h.output(DEBUG, fmt.Sprintf(format, args...))
+}
+func (h *Handle) Infof(format string, args ...interface{}) {
+	h.output(INFO, fmt.Sprintf(format, args...))
+}
+func (h *Handle) Warnf(format string, args ...interface{}) error {
+	msg := fmt.Sprintf(format, args...)
+	h.output(WARNING, msg)
+	return errors.New(msg)
+}
+func (h *Handle) Errorf(format string, args ...interface{}) error {
+	msg := fmt.Sprintf(format, args...)
+	h.output(ERROR, msg)
+	return errors.New(msg)
+}
+func (h *Handle) Criticalf(format string, args ...interface{}) error {
+	msg := fmt.Sprintf(format, args...)
+	h.output(CRITICAL, msg)
+	return errors.New(msg)
+}
+
+func (h *Handle) Close() {
+	h.Logger().Close()
+}
+func (h *Handle) Flush() {
+	h.Logger().Flush()
+}
+func (h *Handle) Closed() bool {
+	return h.Logger().Closed()
+}
+
+func (h *Handle) IsTrace() bool {
+	return h.Logger().IsTrace()
+}
+func (h *Handle) IsDebug() bool {
+	return h.Logger().IsDebug()
+}
+func (h *Handle) IsInfo() bool {
+	return h.Logger().IsInfo()
+}
+func (h *Handle) IsWarn() bool {
+	return h.Logger().IsWarn()
+}
+func (h *Handle) IsError() bool {
+	return h.Logger().IsError()
+}
+func (h *Handle) IsCritical() bool {
+	return h.Logger().IsCritical()
+}
@@ -0,0 +1,189 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const DefaultJournalSocket = "/run/systemd/journal/socket"
+
+type JournalOptions struct {
+	// defaults to DefaultJournalSocket
+	Socket string
+	// SYSLOG_IDENTIFIER, defaults to the program name
+	Identifier string
+}
+
+// JournalWriter sends records to systemd-journald using its native protocol. the
+// level sets PRIORITY, the logger name is sent as LOGGER, the caller as CODE_FILE,
+// CODE_LINE and CODE_FUNC, and fields become journal fields with upper cased keys
+//
+//	w, err := gologging.NewJournalWriter(gologging.JournalOptions{})
+//	gologging.SetLogOutput(w)
+type JournalWriter struct {
+	mu     sync.Mutex
+	addr   *net.UnixAddr
+	conn   *net.UnixConn
+	ident  string
+	closed bool
+}
+
+func NewJournalWriter(opts JournalOptions) (*JournalWriter, error) {
+	if opts.Socket == "" {
+		opts.Socket = DefaultJournalSocket
+	}
+	if opts.Identifier == "" {
+		opts.Identifier = filepath.Base(os.Args[0])
+	}
+	if _, err := os.Stat(opts.Socket); err != nil {
+		return nil, fmt.Errorf("JournalWriter: %s", err)
+	}
+	w := &JournalWriter{
+		addr:  &net.UnixAddr{Name: opts.Socket, Net: "unixgram"},
+		ident: opts.Identifier,
+	}
+	if err := w.connect(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+func (w *JournalWriter) connect() error {
+	if w.conn != nil {
+		w.conn.Close()
+		w.conn = nil
+	}
+	// left unconnected, descriptors can only be passed with sendmsg to an address
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Net: "unixgram"})
+	if err != nil {
+		return fmt.Errorf("JournalWriter: %s", err)
+	}
+	w.conn = conn
+	return nil
+}
+
+// Write sends p as the MESSAGE of an entry at INFO priority
+func (w *JournalWriter) Write(p []byte) (int, error) {
+	r := &Record{Level: INFO, Message: strings.TrimSuffix(string(p), "\n")}
+	if err := w.WriteRecord(r); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (w *JournalWriter) WriteRecord(r *Record) error {
+	data := w.encode(r)
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return os.ErrClosed
+	}
+	err := w.send(data)
+	if err != nil && !isMessageTooLarge(err) {
+		// retry once on a fresh socket
+		if err = w.connect(); err == nil {
+			err = w.send(data)
+		}
+	}
+	return err
+}
+
+func (w *JournalWriter) send(data []byte) error {
+	if w.conn == nil {
+		if err := w.connect(); err != nil {
+			return err
+		}
+	}
+	_, err := w.conn.WriteToUnix(data, w.addr)
+	if err != nil && isMessageTooLarge(err) {
+		// too big for a datagram, pass the entry in a file descriptor instead
+		return sendJournalFd(w.conn, w.addr, data)
+	}
+	return err
+}
+
+func (w *JournalWriter) encode(r *Record) []byte {
+	var b bytes.Buffer
+	writeJournalField(&b, "MESSAGE", r.Message)
+	writeJournalField(&b, "PRIORITY", strconv.Itoa(syslogSeverity(r.Level)))
+	writeJournalField(&b, "SYSLOG_IDENTIFIER", w.ident)
+	if r.Name != "" {
+		writeJournalField(&b, "LOGGER", r.Name)
+	}
+	if r.File != "" && r.File != "???" {
+		writeJournalField(&b, "CODE_FILE", r.File)
+		writeJournalField(&b, "CODE_LINE", strconv.Itoa(r.Line))
+	}
+	if fn := runtime.FuncForPC(r.PC); r.PC != 0 && fn != nil {
+		writeJournalField(&b, "CODE_FUNC", fn.Name())
+	}
+	for _, f := range r.Fields {
+		var value string
+		if s, ok := f.Value.(string); ok {
+			value = s
+		} else {
+			value = fmt.Sprint(f.Value)
+		}
+		writeJournalField(&b, journalKey(f.Key), value)
+	}
+	return b.Bytes()
+}
+
+// KEY=value, or KEY\n<64 bit little endian length>value when the value has a newline
+func writeJournalField(b *bytes.Buffer, key, value string) {
+	b.WriteString(key)
+	if strings.IndexByte(value, '\n') < 0 {
+		b.WriteByte('=')
+		b.WriteString(value)
+		b.WriteByte('\n')
+		return
+	}
+	b.WriteByte('\n')
+	binary.Write(b, binary.LittleEndian, uint64(len(value)))
+	b.WriteString(value)
+	b.WriteByte('\n')
+}
+
+// journal keys are upper case letters, digits and underscores and can not start
+// with an underscore or digit, those are reserved for journald
+func journalKey(key string) string {
+	k := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		}
+		return '_'
+	}, key)
+	if k == "" || k[0] == '_' || (k[0] >= '0' && k[0] <= '9') {
+		k = "F" + k
+	}
+	if len(k) > 64 {
+		k = k[:64]
+	}
+	return k
+}
+
+func (w *JournalWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+	if w.conn == nil {
+		return nil
+	}
+	err := w.conn.Close()
+	w.conn = nil
+	return err
+}
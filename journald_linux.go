@@ -0,0 +1,65 @@
+package logging
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"syscall"
+	"unsafe"
+)
+
+// memfd_create by architecture, the syscall package only defines it for some
+var memfdCreateTrap = map[string]uintptr{
+	"386":      356,
+	"amd64":    319,
+	"arm":      385,
+	"arm64":    279,
+	"loong64":  279,
+	"mips":     4354,
+	"mipsle":   4354,
+	"mips64":   5314,
+	"mips64le": 5314,
+	"ppc64":    360,
+	"ppc64le":  360,
+	"riscv64":  279,
+	"s390x":    350,
+}
+
+const (
+	mfdCloexec       = 0x1
+	mfdAllowSealing  = 0x2
+	fAddSeals        = 0x409
+	fSealSeal        = 0x1
+	fSealShrink      = 0x2
+	fSealGrow        = 0x4
+	fSealWrite       = 0x8
+	journalMemfdName = "journal-entry"
+)
+
+// a sealed memfd holding data, as sd_journal_send passes large entries
+func journalMemfd(data []byte) (*os.File, error) {
+	trap, found := memfdCreateTrap[runtime.GOARCH]
+	if !found {
+		return nil, fmt.Errorf("memfd_create: unknown on %s", runtime.GOARCH)
+	}
+	name, err := syscall.BytePtrFromString(journalMemfdName)
+	if err != nil {
+		return nil, err
+	}
+	fd, _, errno := syscall.Syscall(trap, uintptr(unsafe.Pointer(name)), mfdCloexec|mfdAllowSealing, 0)
+	if errno != 0 {
+		return nil, fmt.Errorf("memfd_create: %s", errno)
+	}
+	f := os.NewFile(fd, journalMemfdName)
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return nil, err
+	}
+	// journald only accepts a memfd nobody can change any more
+	seals := fSealSeal | fSealShrink | fSealGrow | fSealWrite
+	if _, _, errno := syscall.Syscall(syscall.SYS_FCNTL, fd, fAddSeals, uintptr(seals)); errno != 0 {
+		f.Close()
+		return nil, fmt.Errorf("memfd seal: %s", errno)
+	}
+	return f, nil
+}
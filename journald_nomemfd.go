@@ -0,0 +1,12 @@
+//go:build unix && !linux
+
+package logging
+
+import (
+	"errors"
+	"os"
+)
+
+func journalMemfd(data []byte) (*os.File, error) {
+	return nil, errors.New("memfd_create: not supported")
+}
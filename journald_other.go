@@ -0,0 +1,16 @@
+//go:build !unix
+
+package logging
+
+import (
+	"errors"
+	"net"
+)
+
+func isMessageTooLarge(err error) bool {
+	return false
+}
+
+func sendJournalFd(conn *net.UnixConn, addr *net.UnixAddr, data []byte) error {
+	return errors.New("JournalWriter: passing entries by file descriptor is not supported")
+}
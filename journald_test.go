@@ -0,0 +1,172 @@
+//go:build linux
+
+package logging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// a stand-in for journald listening on a unixgram socket
+func listenJournal(t *testing.T) (*net.UnixConn, string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "journal.sock")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn, path
+}
+
+// the fields of an entry, KEY=value lines or KEY\n<length>value\n
+func parseJournalEntry(t *testing.T, data []byte) map[string]string {
+	t.Helper()
+	fields := map[string]string{}
+	for len(data) > 0 {
+		i := bytes.IndexAny(data, "=\n")
+		if i < 0 {
+			t.Fatalf("bad entry: %q", data)
+		}
+		key := string(data[:i])
+		if data[i] == '=' {
+			end := bytes.IndexByte(data, '\n')
+			fields[key] = string(data[i+1 : end])
+			data = data[end+1:]
+			continue
+		}
+		n := binary.LittleEndian.Uint64(data[i+1 : i+9])
+		value := data[i+9 : i+9+int(n)]
+		if data[i+9+int(n)] != '\n' {
+			t.Fatalf("%s: value not followed by a newline", key)
+		}
+		fields[key] = string(value)
+		data = data[i+10+int(n):]
+	}
+	return fields
+}
+
+func TestJournalFields(t *testing.T) {
+	conn, path := listenJournal(t)
+	w, err := NewJournalWriter(JournalOptions{Socket: path, Identifier: "testapp"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	r := newRecord(1, ERROR, "db.pool", "line one\nline two", []Field{
+		{Key: "user-id", Value: 42},
+		{Key: "query", Value: "select 1\nfrom t"},
+	})
+	if err := w.WriteRecord(r); err != nil {
+		t.Fatal(err)
+	}
+	buf := make([]byte, 65536)
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, err := conn.Read(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fields := parseJournalEntry(t, buf[:n])
+	want := map[string]string{
+		"MESSAGE":           "line one\nline two",
+		"PRIORITY":          "3",
+		"SYSLOG_IDENTIFIER": "testapp",
+		"LOGGER":            "db.pool",
+		"CODE_FILE":         r.File,
+		"CODE_LINE":         strconv.Itoa(r.Line),
+		"USER_ID":           "42",
+		"QUERY":             "select 1\nfrom t",
+	}
+	for k, v := range want {
+		if fields[k] != v {
+			t.Errorf("%s: %q, want %q", k, fields[k], v)
+		}
+	}
+	if !strings.HasSuffix(fields["CODE_FILE"], "journald_test.go") {
+		t.Errorf("CODE_FILE: %s", fields["CODE_FILE"])
+	}
+	if !strings.HasSuffix(fields["CODE_FUNC"], "TestJournalFields") {
+		t.Errorf("CODE_FUNC: %s", fields["CODE_FUNC"])
+	}
+}
+
+// an entry too large for a datagram is passed in a sealed memfd
+func TestJournalLargeEntry(t *testing.T) {
+	conn, path := listenJournal(t)
+	w, err := NewJournalWriter(JournalOptions{Socket: path, Identifier: "testapp"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	msg := strings.Repeat("x", 4<<20)
+	errc := make(chan error, 1)
+	go func() {
+		errc <- w.WriteRecord(&Record{Level: INFO, Message: msg})
+	}()
+	oob := make([]byte, syscall.CmsgSpace(4))
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, oobn, _, _, err := conn.ReadMsgUnix(make([]byte, 16), oob)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := <-errc; err != nil {
+		t.Fatal(err)
+	}
+	if n != 0 {
+		t.Errorf("datagram: %d bytes, want only a descriptor", n)
+	}
+	msgs, err := syscall.ParseSocketControlMessage(oob[:oobn])
+	if err != nil || len(msgs) != 1 {
+		t.Fatalf("control message: %v %v", msgs, err)
+	}
+	fds, err := syscall.ParseUnixRights(&msgs[0])
+	if err != nil || len(fds) != 1 {
+		t.Fatalf("descriptors: %v %v", fds, err)
+	}
+	f := os.NewFile(uintptr(fds[0]), "entry")
+	defer f.Close()
+
+	link, _ := os.Readlink("/proc/self/fd/" + strconv.Itoa(fds[0]))
+	if !strings.HasPrefix(link, "/memfd:") {
+		t.Errorf("descriptor: %s, want a memfd", link)
+	}
+	// F_GET_SEALS
+	seals, _, errno := syscall.Syscall(syscall.SYS_FCNTL, uintptr(fds[0]), 0x40a, 0)
+	if errno != 0 || seals&fSealWrite == 0 {
+		t.Errorf("seals: %#x %v", seals, errno)
+	}
+
+	data := make([]byte, len(msg)+4096)
+	n, _ = f.ReadAt(data, 0)
+	fields := parseJournalEntry(t, data[:n])
+	if fields["MESSAGE"] != msg || fields["SYSLOG_IDENTIFIER"] != "testapp" {
+		t.Errorf("entry: %d byte message, identifier %q", len(fields["MESSAGE"]), fields["SYSLOG_IDENTIFIER"])
+	}
+}
+
+// the temporary file used where memfd_create is not available
+func TestJournalTempFile(t *testing.T) {
+	f, err := journalTempFile([]byte("MESSAGE=hello\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	data := make([]byte, 64)
+	n, _ := f.ReadAt(data, 0)
+	if string(data[:n]) != "MESSAGE=hello\n" {
+		t.Errorf("content: %q", data[:n])
+	}
+	if _, err := os.Stat(f.Name()); !os.IsNotExist(err) {
+		t.Errorf("%s: not removed", f.Name())
+	}
+}
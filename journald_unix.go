@@ -0,0 +1,51 @@
+//go:build unix
+
+package logging
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"syscall"
+)
+
+func isMessageTooLarge(err error) bool {
+	return errors.Is(err, syscall.EMSGSIZE) || errors.Is(err, syscall.ENOBUFS)
+}
+
+// pass data in a file descriptor, which journald reads the entry from. a sealed
+// memfd is used where there is one, else an unlinked temporary file as
+// sd_journal_send does on older kernels
+func sendJournalFd(conn *net.UnixConn, addr *net.UnixAddr, data []byte) error {
+	f, err := journalMemfd(data)
+	if err != nil {
+		f, err = journalTempFile(data)
+	}
+	if err != nil {
+		return fmt.Errorf("JournalWriter: %s", err)
+	}
+	defer f.Close()
+	_, _, err = conn.WriteMsgUnix(nil, syscall.UnixRights(int(f.Fd())), addr)
+	if err != nil {
+		return fmt.Errorf("JournalWriter: %s", err)
+	}
+	return nil
+}
+
+func journalTempFile(data []byte) (*os.File, error) {
+	dir := "/dev/shm"
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		dir = os.TempDir()
+	}
+	f, err := os.CreateTemp(dir, "journal.")
+	if err != nil {
+		return nil, err
+	}
+	os.Remove(f.Name())
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return f, nil
+}
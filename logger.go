@@ -6,6 +6,11 @@ type Logger interface {
 	GetLevel() string
 	SetLevel(level string) error
 	SetWriter(io.Writer)
+	SetEncoder(Encoder)
+
+	// With returns a child logger which emits keyvals as fields on every line
+	With(keyvals ...interface{}) Logger
+	WithFields(fields map[string]interface{}) Logger
 
 	Tracef(format string, params ...interface{})
 	Debugf(format string, params ...interface{})
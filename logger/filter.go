@@ -0,0 +1,119 @@
+package logger
+
+import (
+	"regexp"
+	"sort"
+)
+
+// LogFilter decides whether a message continues down the pipeline
+type LogFilter interface {
+	Allow(Message) bool
+}
+
+// a predicate filter
+//
+//	Build().WithFilter(FilterFunc(func(m Message) bool { return !strings.HasPrefix(m.String(), "health") }))
+type FilterFunc func(Message) bool
+
+func (f FilterFunc) Allow(m Message) bool {
+	return f(m)
+}
+
+// LogFilterLevel passes messages at lvl or more severe, LvlCritical is the most severe
+type LogFilterLevel struct {
+	lvl Level
+}
+
+func LevelFilter(lvl Level) LogFilter {
+	return &LogFilterLevel{lvl}
+}
+
+func (l *LogFilterLevel) Allow(m Message) bool {
+	return m.Level() <= l.lvl
+}
+
+// pass only messages whose text matches re
+func IncludeFilter(re *regexp.Regexp) LogFilter {
+	return FilterFunc(func(m Message) bool {
+		return re.MatchString(m.String())
+	})
+}
+
+// drop messages whose text matches re
+func ExcludeFilter(re *regexp.Regexp) LogFilter {
+	return FilterFunc(func(m Message) bool {
+		return !re.MatchString(m.String())
+	})
+}
+
+// NewLogFilter returns a stage passing messages at lvl or more severe to writer
+func NewLogFilter(lvl Level, writer LogWriter) LogWriter {
+	return NewFilterChain(writer, LevelFilter(lvl))
+}
+
+// NewFilterChain returns a stage passing messages allowed by every filter, in order, to writer
+func NewFilterChain(writer LogWriter, filters ...LogFilter) LogWriter {
+	c := &FilterChain{filters: filters, writer: writer}
+	c.worker = newWorker(200, c.handle, c.flushWriter)
+	return c
+}
+
+type FilterChain struct {
+	*worker
+	filters []LogFilter
+	writer  LogWriter
+}
+
+func (c *FilterChain) handle(message Message) {
+	for _, f := range c.filters {
+		if !f.Allow(message) {
+			return
+		}
+	}
+	c.writer.Write(message)
+}
+func (c *FilterChain) flushWriter() {
+	flushWriter(c.writer)
+}
+
+// sends each message to the writer for its level, others to fallback. it runs on
+// the goroutine of the stage before it and the writers are stages of their own
+type levelRouter struct {
+	routes   map[Level]LogWriter
+	fallback LogWriter
+}
+
+func (r *levelRouter) Write(m Message) {
+	if w, found := r.routes[m.Level()]; found {
+		w.Write(m)
+		return
+	}
+	r.fallback.Write(m)
+}
+
+// the fallback and each routed writer once, in level order
+func (r *levelRouter) outputs() []LogWriter {
+	levels := []Level{}
+	for lvl := range r.routes {
+		levels = append(levels, lvl)
+	}
+	sort.Slice(levels, func(i, j int) bool { return levels[i] < levels[j] })
+	outputs := []LogWriter{r.fallback}
+	for _, lvl := range levels {
+		w := r.routes[lvl]
+		found := false
+		for _, o := range outputs {
+			found = found || o == w
+		}
+		if !found {
+			outputs = append(outputs, w)
+		}
+	}
+	return outputs
+}
+
+func (r *levelRouter) Flush() {
+	for _, w := range r.outputs() {
+		flushWriter(w)
+	}
+}
@@ -0,0 +1,170 @@
+package logger
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// a message logged at level with the text msg
+type logged struct {
+	lvl Level
+	msg string
+}
+
+func logAll(log Logger, messages []logged) {
+	for _, m := range messages {
+		switch m.lvl {
+		case LvlCritical:
+			log.Critical(m.msg)
+		case LvlError:
+			log.Error(m.msg)
+		case LvlWarn:
+			log.Warn(m.msg)
+		case LvlInfo:
+			log.Info(m.msg)
+		case LvlDebug:
+			log.Debug(m.msg)
+		case LvlTrace:
+			log.Trace(m.msg)
+		}
+	}
+	log.Flush()
+}
+
+var allLevels = []logged{
+	{LvlCritical, "critical"},
+	{LvlError, "error"},
+	{LvlWarn, "warn"},
+	{LvlInfo, "info"},
+	{LvlDebug, "debug"},
+	{LvlTrace, "trace"},
+}
+
+func TestLevelFilter(t *testing.T) {
+	tests := []struct {
+		lvl  Level
+		want string
+	}{
+		{LvlCritical, "critical"},
+		{LvlError, "critical,error"},
+		{LvlWarn, "critical,error,warn"},
+		{LvlInfo, "critical,error,warn,info"},
+		{LvlDebug, "critical,error,warn,info,debug"},
+		{LvlTrace, "critical,error,warn,info,debug,trace"},
+	}
+	for _, tt := range tests {
+		out := newCollector(0)
+		log := Build().WithLevel(tt.lvl).WithOutput(out).Create()
+		logAll(log, allLevels)
+		log.Close()
+		if got := strings.Join(out.messages(), ","); got != tt.want {
+			t.Errorf("level %d: got %s, want %s", tt.lvl, got, tt.want)
+		}
+	}
+}
+
+func TestIncludeExclude(t *testing.T) {
+	db := regexp.MustCompile("^db")
+	slow := regexp.MustCompile("slow")
+	messages := []logged{
+		{LvlInfo, "db query"},
+		{LvlInfo, "db slow query"},
+		{LvlInfo, "http request"},
+		{LvlInfo, "http slow request"},
+		{LvlDebug, "db debug"},
+	}
+	tests := []struct {
+		name    string
+		filters []LogFilter
+		want    string
+	}{
+		{"none", nil, "db query,db slow query,http request,http slow request"},
+		{"include", []LogFilter{IncludeFilter(db)}, "db query,db slow query"},
+		{"exclude", []LogFilter{ExcludeFilter(slow)}, "db query,http request"},
+		{"include then exclude", []LogFilter{IncludeFilter(db), ExcludeFilter(slow)}, "db query"},
+		{"exclude then include", []LogFilter{ExcludeFilter(slow), IncludeFilter(db)}, "db query"},
+		{"two includes", []LogFilter{IncludeFilter(db), IncludeFilter(slow)}, "db slow query"},
+	}
+	for _, tt := range tests {
+		out := newCollector(0)
+		log := Build().WithLevel(LvlInfo).WithFilter(tt.filters...).WithOutput(out).Create()
+		logAll(log, messages)
+		log.Close()
+		if got := strings.Join(out.messages(), ","); got != tt.want {
+			t.Errorf("%s: got %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+// filters run in the order given, after the level, and stop at the first to refuse
+func TestFilterOrder(t *testing.T) {
+	var calls []string
+	record := func(name string, allow bool) LogFilter {
+		return FilterFunc(func(m Message) bool {
+			calls = append(calls, name+":"+m.String())
+			return allow
+		})
+	}
+	out := newCollector(0)
+	log := Build().WithLevel(LvlInfo).
+		WithFilter(record("first", true), record("second", false), record("third", true)).
+		WithOutput(out).Create()
+	logAll(log, []logged{{LvlDebug, "debug"}, {LvlInfo, "info"}})
+	log.Close()
+
+	if got, want := strings.Join(calls, ","), "first:info,second:info"; got != want {
+		t.Errorf("calls: %s, want %s", got, want)
+	}
+	if got := out.messages(); len(got) != 0 {
+		t.Errorf("written: %q", got)
+	}
+}
+
+func TestRoutes(t *testing.T) {
+	tests := []struct {
+		name   string
+		routes map[Level]string
+		// the messages written to each output
+		want map[string]string
+	}{
+		{
+			"none",
+			nil,
+			map[string]string{"fallback": "critical,error,warn,info"},
+		},
+		{
+			"errors",
+			map[Level]string{LvlError: "errors"},
+			map[string]string{"fallback": "critical,warn,info", "errors": "error"},
+		},
+		{
+			"shared output",
+			map[Level]string{LvlCritical: "alerts", LvlError: "alerts", LvlInfo: "info"},
+			map[string]string{"fallback": "warn", "alerts": "critical,error", "info": "info"},
+		},
+		{
+			"filtered level",
+			map[Level]string{LvlDebug: "debug", LvlWarn: "warn"},
+			map[string]string{"fallback": "critical,error,info", "debug": "", "warn": "warn"},
+		},
+	}
+	for _, tt := range tests {
+		outputs := map[string]*collector{"fallback": newCollector(0)}
+		b := Build().WithLevel(LvlInfo).WithOutput(outputs["fallback"])
+		for lvl, name := range tt.routes {
+			if outputs[name] == nil {
+				outputs[name] = newCollector(0)
+			}
+			b.WithRoute(lvl, outputs[name])
+		}
+		log := b.Create()
+		logAll(log, allLevels)
+		log.Close()
+		for name, want := range tt.want {
+			if got := strings.Join(outputs[name].messages(), ","); got != want {
+				t.Errorf("%s: %s got %s, want %s", tt.name, name, got, want)
+			}
+		}
+	}
+}
@@ -2,6 +2,7 @@ package logger
 
 import (
 	"fmt"
+	"sync"
 )
 
 type Logger interface {
@@ -20,21 +21,24 @@ type Logger interface {
 	Critical(v ...interface{}) error
 
 	Message(Message)
+
+	// Flush waits until every message logged before is written
+	Flush()
+	// Close writes the queued messages and stops the goroutines of the pipeline
+	Close()
+	// Update returns a builder with the current settings, its Update() swaps the pipeline
+	//
+	//	log.Update().WithLevel(LvlDebug).Update()
+	Update() *Builder
 }
 type InnerLogger interface {
 	FormatMessageLevel(lvl Level, format string, params ...interface{})
 	ListMessageLevel(lvl Level, params ...interface{})
 	Message(Message)
-	//TODO: Flush() Idea: have a non-buffered chan for flush, that you check only when there are
-	// no more messages in the messsage queue, then this could bubble down to Writer, and back up.
-	//TODO: Update().Reset().WithConfig("foo.yaml").WithLevel().Update()
-	// the last Update() is the builder create()/replace of the LogWriter.
-	// its important to note that the Writer can only be swapped, once the existing filterchain is dried up.
-	// so, this Update() method must
-	// 1. spawn a goroutine to ReplaceWriter() on the Current FilterChain's tail, and close the oldWriter
-	// 2. start the new one, and hook it up to the logger
-	// 3. close() the existing filterchain
-	// 4. once existing filterchain is done for, exit.
+	// a flush is only picked up by a stage once its queue is empty, then bubbles
+	// down to the writer, and back up
+	Flush()
+	Close()
 }
 type Message interface {
 	String() string
@@ -47,8 +51,24 @@ type Formatter interface {
 type LogWriter interface {
 	Write(Message)
 }
-type LogFilter interface {
-	LogWriter
+
+// LogWriters which queue messages implement Flusher and Closer
+type Flusher interface {
+	Flush()
+}
+type Closer interface {
+	Close()
+}
+
+func flushWriter(w LogWriter) {
+	if f, ok := w.(Flusher); ok {
+		f.Flush()
+	}
+}
+func closeWriter(w LogWriter) {
+	if c, ok := w.(Closer); ok {
+		c.Close()
+	}
 }
 
 func Build() *Builder {
@@ -56,13 +76,44 @@ func Build() *Builder {
 }
 
 type Builder struct {
-	lvl       Level
+	lvl Level
+	// WithLevel was called, LvlCritical is the zero value
+	hasLevel  bool
+	filters   []LogFilter
+	routes    map[Level]LogWriter
 	output    LogWriter
 	formatter Formatter
+	// the logger Update() replaces the pipeline of
+	target *LoggerWrapper
+}
+
+// back to the settings of Build(), the output is created again on Update()
+func (b *Builder) Reset() *Builder {
+	*b = Builder{formatter: &DefaultFormatter{}, target: b.target}
+	return b
 }
 
+// pass messages at lvl or more severe
 func (b *Builder) WithLevel(lvl Level) *Builder {
 	b.lvl = lvl
+	b.hasLevel = true
+	return b
+}
+
+// add filters to the chain, a message must be allowed by each in turn
+//
+//	Build().WithLevel(LvlInfo).WithFilter(ExcludeFilter(regexp.MustCompile("^health"))).Create()
+func (b *Builder) WithFilter(filters ...LogFilter) *Builder {
+	b.filters = append(b.filters, filters...)
+	return b
+}
+
+// write messages at lvl to output instead of the default output
+func (b *Builder) WithRoute(lvl Level, output LogWriter) *Builder {
+	if b.routes == nil {
+		b.routes = make(map[Level]LogWriter)
+	}
+	b.routes[lvl] = output
 	return b
 }
 
@@ -75,17 +126,69 @@ func (b *Builder) WithFormatter(formatter Formatter) *Builder {
 	return b
 }
 
-func (b *Builder) Create() Logger {
+// the settings a logger keeps for Update, the slices and maps are not shared
+func (b *Builder) settings() Builder {
+	s := *b
+	s.target = nil
+	s.filters = append([]LogFilter(nil), b.filters...)
+	s.routes = copyRoutes(b.routes)
+	return s
+}
+
+func copyRoutes(routes map[Level]LogWriter) map[Level]LogWriter {
+	if routes == nil {
+		return nil
+	}
+	c := make(map[Level]LogWriter, len(routes))
+	for lvl, w := range routes {
+		c[lvl] = w
+	}
+	return c
+}
+
+// the writer messages are written to and the stages in the order they are closed,
+// the filter chain and then the outputs
+func (b *Builder) pipeline() (LogWriter, []LogWriter) {
 	if b.output == nil {
 		b.output = NewLogWriter()
 	}
-	var filter LogWriter
-	if b.lvl != 0 {
-		filter = NewLogFilter(b.lvl, b.output)
-	} else {
-		filter = b.output
+	var writer LogWriter = b.output
+	stages := []LogWriter{b.output}
+	if len(b.routes) > 0 {
+		router := &levelRouter{routes: copyRoutes(b.routes), fallback: b.output}
+		writer = router
+		stages = router.outputs()
+	}
+	filters := b.filters
+	if b.hasLevel {
+		filters = append([]LogFilter{LevelFilter(b.lvl)}, filters...)
 	}
-	return &LoggerWrapper{&LoggerImpl{filter, b.formatter}}
+	if len(filters) > 0 {
+		writer = NewFilterChain(writer, filters...)
+		stages = append([]LogWriter{writer}, stages...)
+	}
+	return writer, stages
+}
+
+func (b *Builder) Create() Logger {
+	l := &LoggerImpl{formatter: b.formatter}
+	l.writer, l.stages = b.pipeline()
+	l.settings = b.settings()
+	return &LoggerWrapper{l}
+}
+
+// swap the pipeline of the logger the builder came from, see Logger.Update. messages
+// queued in the old pipeline are written before any message reaches the new one.
+// a builder not made by Logger.Update creates a new logger
+func (b *Builder) Update() Logger {
+	if b.target == nil {
+		return b.Create()
+	}
+	if impl, ok := b.target.logger.(*LoggerImpl); ok {
+		writer, stages := b.pipeline()
+		impl.replace(writer, stages, b.settings())
+	}
+	return b.target
 }
 
 //TODO: define constants
@@ -107,6 +210,22 @@ type LoggerWrapper struct {
 func (l *LoggerWrapper) Message(m Message) {
 	l.logger.Message(m)
 }
+func (l *LoggerWrapper) Flush() {
+	l.logger.Flush()
+}
+func (l *LoggerWrapper) Close() {
+	l.logger.Close()
+}
+func (l *LoggerWrapper) Update() *Builder {
+	b := Build()
+	if impl, ok := l.logger.(*LoggerImpl); ok {
+		impl.mu.RLock()
+		*b = impl.settings.settings()
+		impl.mu.RUnlock()
+	}
+	b.target = l
+	return b
+}
 func (l *LoggerWrapper) Criticalf(msg string, params ...interface{}) error {
 	l.logger.FormatMessageLevel(LvlCritical, msg, params...)
 	return fmt.Errorf(msg, params...)
@@ -152,18 +271,84 @@ func (l *LoggerWrapper) Trace(params ...interface{}) {
 }
 
 type LoggerImpl struct {
-	writer    LogWriter
+	mu sync.RWMutex
+	// the head of the pipeline, each stage writes to the next
+	writer LogWriter
+	// closed in order so each drains into the next
+	stages    []LogWriter
 	formatter Formatter
+	// the builder settings, for Update
+	settings Builder
+	closed   bool
 }
 
 func (l *LoggerImpl) Message(m Message) {
-	l.writer.Write(m)
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	if !l.closed {
+		l.writer.Write(m)
+	}
 }
 func (l *LoggerImpl) FormatMessageLevel(lvl Level, msg string, params ...interface{}) {
-	l.Message(l.formatter.CreateMessage(lvl, msg, params...))
+	l.mu.RLock()
+	formatter := l.formatter
+	l.mu.RUnlock()
+	l.Message(formatter.CreateMessage(lvl, msg, params...))
 }
 func (l *LoggerImpl) ListMessageLevel(lvl Level, params ...interface{}) {
-	l.Message(l.formatter.CreateMessageList(lvl, params...))
+	l.mu.RLock()
+	formatter := l.formatter
+	l.mu.RUnlock()
+	l.Message(formatter.CreateMessageList(lvl, params...))
+}
+
+// the flush bubbles down from the first stage
+func (l *LoggerImpl) Flush() {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	if !l.closed {
+		flushWriter(l.writer)
+	}
+}
+
+func (l *LoggerImpl) Close() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.closed {
+		return
+	}
+	l.closed = true
+	closeStages(l.stages, nil)
+}
+
+// close stages in order so each drains into the next before that is closed,
+// stages also in keep are left running
+func closeStages(stages []LogWriter, keep []LogWriter) {
+	for _, s := range stages {
+		kept := false
+		for _, k := range keep {
+			kept = kept || k == s
+		}
+		if !kept {
+			closeWriter(s)
+		}
+	}
+}
+
+// swap in a new pipeline once the old one is drained. logging waits meanwhile so
+// nothing is lost or written out of order
+func (l *LoggerImpl) replace(writer LogWriter, stages []LogWriter, settings Builder) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.closed {
+		closeStages(stages, nil)
+		return
+	}
+	closeStages(l.stages, stages)
+	l.writer = writer
+	l.stages = stages
+	l.formatter = settings.formatter
+	l.settings = settings
 }
 
 type FormatMessage struct {
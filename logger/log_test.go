@@ -0,0 +1,137 @@
+package logger
+
+import (
+	"fmt"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// a LogWriter stage collecting the messages it is given
+type collector struct {
+	*worker
+	mu  sync.Mutex
+	got []string
+	// slows down handling so messages queue up
+	delay time.Duration
+}
+
+func newCollector(delay time.Duration) *collector {
+	c := &collector{delay: delay}
+	c.worker = newWorker(200, c.handle, func() {})
+	return c
+}
+
+func (c *collector) handle(m Message) {
+	time.Sleep(c.delay)
+	c.mu.Lock()
+	c.got = append(c.got, m.String())
+	c.mu.Unlock()
+}
+
+func (c *collector) messages() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]string(nil), c.got...)
+}
+
+// wait for the goroutines of closed pipelines to exit
+func checkGoroutines(t *testing.T, baseline int) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for runtime.NumGoroutine() > baseline && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if n := runtime.NumGoroutine(); n > baseline {
+		t.Errorf("goroutines: %d, want %d", n, baseline)
+	}
+}
+
+// the messages must be "0".."n-1" in order
+func checkSequence(t *testing.T, got []string, n int) {
+	t.Helper()
+	if len(got) != n {
+		t.Fatalf("messages: %d, want %d", len(got), n)
+	}
+	for i, m := range got {
+		if m != strconv.Itoa(i) {
+			t.Fatalf("message %d: %q, want %d", i, m, i)
+		}
+	}
+}
+
+func TestCreateClose(t *testing.T) {
+	baseline := runtime.NumGoroutine()
+	for i := 0; i < 20; i++ {
+		out := newCollector(0)
+		log := Build().WithLevel(LvlInfo).WithOutput(out).Create()
+		log.Info("info")
+		log.Debug("debug")
+		log.Close()
+		log.Info("after close")
+		log.Flush()
+		log.Close()
+		if got := out.messages(); len(got) != 1 || got[0] != "info" {
+			t.Fatalf("messages: %q, want [info]", got)
+		}
+	}
+	// the default output
+	log := Build().Create()
+	log.Close()
+	checkGoroutines(t, baseline)
+}
+
+func TestFlushOrder(t *testing.T) {
+	baseline := runtime.NumGoroutine()
+	out := newCollector(10 * time.Microsecond)
+	log := Build().WithLevel(LvlTrace).WithOutput(out).Create()
+	for i := 0; i < 500; i++ {
+		log.Info(i)
+	}
+	log.Flush()
+	// everything logged before Flush is written when it returns
+	checkSequence(t, out.messages(), 500)
+	log.Close()
+	checkGoroutines(t, baseline)
+}
+
+func TestUpdate(t *testing.T) {
+	baseline := runtime.NumGoroutine()
+	first := newCollector(10 * time.Microsecond)
+	second := newCollector(0)
+	log := Build().WithLevel(LvlInfo).WithOutput(first).Create()
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 1000; i++ {
+			log.Info(i)
+		}
+	}()
+	time.Sleep(time.Millisecond)
+	// swap the output while messages are queued, the first output keeps what was
+	// logged before
+	log.Update().WithOutput(second).Update()
+	wg.Wait()
+	log.Flush()
+
+	got := append(first.messages(), second.messages()...)
+	checkSequence(t, got, 1000)
+
+	// swap the level, the output is kept
+	log.Update().WithLevel(LvlError).Update()
+	log.Info("info")
+	log.Error("error")
+	log.Flush()
+	if got := second.messages(); got[len(got)-1] != "error" || strings.Contains(fmt.Sprint(got), "info") {
+		t.Errorf("after level update: %q", got[len(got)-1])
+	}
+
+	log.Close()
+	first.Close()
+	checkGoroutines(t, baseline)
+}
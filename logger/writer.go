@@ -1,60 +1,98 @@
 package logger
 
-func NewLogFilter(lvl Level, writer LogWriter) LogWriter {
-	ch := make(chan Message, 200)
-	return &LogFilterLevel{ch, lvl, writer, false}
-}
+import "sync"
 
-type LogFilterLevel struct {
-	input   chan Message
-	lvl     Level
-	writer  LogWriter
-	started bool
+// the single goroutine of a stage. messages are handled in order, a flush is only
+// picked up when no messages are queued, bubbled down and then acknowledged
+type worker struct {
+	ch     chan Message
+	flush  chan chan struct{}
+	done   chan struct{}
+	mu     sync.RWMutex
+	closed bool
 }
 
-func (l *LogFilterLevel) Write(m Message) {
-	if !l.started {
-		go l.Start()
+func newWorker(size int, handle func(Message), flush func()) *worker {
+	w := &worker{
+		ch:    make(chan Message, size),
+		flush: make(chan chan struct{}),
+		done:  make(chan struct{}),
 	}
-	l.input <- m
+	go w.run(handle, flush)
+	return w
 }
-func (l *LogFilterLevel) Start() {
+
+func (w *worker) run(handle func(Message), flush func()) {
+	defer close(w.done)
 	for {
 		select {
-		case message, _ := <-l.input:
-			if message.Level() >= l.lvl {
-				l.writer.Write(message)
+		case message, ok := <-w.ch:
+			if !ok {
+				return
+			}
+			handle(message)
+		default:
+			select {
+			case message, ok := <-w.ch:
+				if !ok {
+					return
+				}
+				handle(message)
+			case ack := <-w.flush:
+				flush()
+				close(ack)
 			}
 		}
 	}
 }
 
-func NewLogWriter() LogWriter {
-	ch := make(chan Message, 200)
-	return &LogWriterStdout{ch: ch, started: false}
+// messages written after Close are dropped
+func (w *worker) Write(m Message) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	if w.closed {
+		return
+	}
+	w.ch <- m
 }
 
-type LogWriterStdout struct {
-	ch      chan Message
-	started bool
+// wait until every message written before is handled by this and later stages
+func (w *worker) Flush() {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	if w.closed {
+		return
+	}
+	ack := make(chan struct{})
+	w.flush <- ack
+	<-ack
 }
 
-func (c *LogWriterStdout) Write(m Message) {
-	if !c.started {
-		go c.Start()
+// handle the queued messages and stop the goroutine
+func (w *worker) Close() {
+	w.mu.Lock()
+	if !w.closed {
+		w.closed = true
+		close(w.ch)
 	}
-	c.ch <- m
+	w.mu.Unlock()
+	<-w.done
+}
+
+func NewLogWriter() LogWriter {
+	c := &LogWriterStdout{}
+	c.worker = newWorker(200, c.handle, func() {})
+	return c
+}
+
+type LogWriterStdout struct {
+	*worker
 }
 
 func (c *LogWriterStdout) MessageChan() chan Message {
 	return c.ch
 }
 
-func (c *LogWriterStdout) Start() {
-	for {
-		select {
-		case message, _ := <-c.ch:
-			println(message.String())
-		}
-	}
+func (c *LogWriterStdout) handle(message Message) {
+	println(message.String())
 }
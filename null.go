@@ -10,6 +10,11 @@ func NewNullLogger() Logger {
 }
 
 func (l *NullLogger) SetWriter(io.Writer)                {}
+func (l *NullLogger) SetEncoder(Encoder)                 {}
+func (l *NullLogger) With(keyvals ...interface{}) Logger { return l }
+func (l *NullLogger) WithFields(fields map[string]interface{}) Logger {
+	return l
+}
 func (l *NullLogger) Debug(args ...interface{})          {}
 func (l *NullLogger) Info(args ...interface{})           {}
 func (l *NullLogger) Warn(args ...interface{}) error     { return nil }
@@ -24,6 +29,8 @@ func (l *NullLogger) Errorf(s string, args ...interface{}) error    { return nil
 func (l *NullLogger) Criticalf(s string, args ...interface{}) error { return nil }
 func (l *NullLogger) Tracef(s string, args ...interface{})          {}
 
+func (l *NullLogger) Log(r *Record) error { return nil }
+
 // methods to implement the Logger interface
 func (l *NullLogger) SetLevel(level string) error {
 	return nil
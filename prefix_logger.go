@@ -16,6 +16,18 @@ func NewPrefixLogger(prefix string, l Logger) *PrefixLogger {
 
 func (p *PrefixLogger) SetWriter(io.Writer) {
 }
+func (p *PrefixLogger) SetEncoder(Encoder) {
+}
+
+func (p *PrefixLogger) With(keyvals ...interface{}) Logger {
+	return &PrefixLogger{
+		log:    p.log.With(keyvals...),
+		Prefix: p.Prefix,
+	}
+}
+func (p *PrefixLogger) WithFields(fields map[string]interface{}) Logger {
+	return p.With(mapToKeyvals(fields)...)
+}
 
 func (p *PrefixLogger) SetLevel(level string) error {
 	return nil
@@ -71,6 +83,11 @@ func (p *PrefixLogger) Critical(v ...interface{}) error {
 	return p.log.Critical(p.prefix(v)...)
 }
 
+func (p *PrefixLogger) Log(r *Record) error {
+	r.Message = p.Prefix + r.Message
+	return logRecord(p.log, r)
+}
+
 func (p *PrefixLogger) Close() {
 	p.log.Close()
 }
@@ -0,0 +1,115 @@
+package logging
+
+import (
+	"runtime"
+	"strings"
+	"time"
+)
+
+// Record is a single log line as handed to an Encoder
+type Record struct {
+	Time    time.Time
+	Level   int
+	Name    string
+	File    string
+	Line    int
+	PC      uintptr
+	Message string
+	Fields  []Field
+}
+
+// RecordLogger is implemented by loggers which can write a prebuilt Record. this
+// lets wrappers and adapters supply the caller instead of pointing at themselves
+type RecordLogger interface {
+	Log(r *Record) error
+}
+
+// RecordWriter is implemented by outputs which need the whole record rather than
+// encoded bytes, eg. syslog using the level as the severity. loggers hand records
+// straight to these and skip their encoder
+type RecordWriter interface {
+	WriteRecord(r *Record) error
+}
+
+// implemented by outputs which keep records and encode them later, eg. AsyncWriter.
+// loggers pass their encoder along so the output format stays theirs
+type encoderRecordWriter interface {
+	writeRecordWith(r *Record, encoder Encoder) error
+}
+
+// hand r to rw with the encoder of the logger, for those which want it
+func writeRecordTo(rw RecordWriter, r *Record, encoder Encoder) error {
+	if ew, ok := rw.(encoderRecordWriter); ok {
+		return ew.writeRecordWith(r, encoder)
+	}
+	return rw.WriteRecord(r)
+}
+
+// write r through l, falling back to the level methods for loggers which are not a RecordLogger
+func logRecord(l Logger, r *Record) error {
+	if rl, ok := l.(RecordLogger); ok {
+		return rl.Log(r)
+	}
+	if len(r.Fields) > 0 {
+		l = l.With(fieldsToKeyvals(r.Fields)...)
+	}
+	switch {
+	case r.Level >= CRITICAL:
+		l.Critical(r.Message)
+	case r.Level >= ERROR:
+		l.Error(r.Message)
+	case r.Level >= WARNING:
+		l.Warn(r.Message)
+	case r.Level >= INFO:
+		l.Info(r.Message)
+	case r.Level >= DEBUG:
+		l.Debug(r.Message)
+	default:
+		l.Trace(r.Message)
+	}
+	return nil
+}
+
+// true when l would write a line at level
+func isEnabled(l Logger, level int) bool {
+	switch {
+	case level >= CRITICAL:
+		return l.IsCritical()
+	case level >= ERROR:
+		return l.IsError()
+	case level >= WARNING:
+		return l.IsWarn()
+	case level >= INFO:
+		return l.IsInfo()
+	case level >= DEBUG:
+		return l.IsDebug()
+	}
+	return l.IsTrace()
+}
+
+// build a record with caller information. calldepth counts frames as runtime.Caller
+// does from inside newRecord, so 1 is the function calling newRecord
+func newRecord(calldepth int, level int, name string, msg string, fields []Field) *Record {
+	r := &Record{
+		Time:    time.Now(),
+		Level:   level,
+		Name:    name,
+		Message: strings.TrimSuffix(msg, "\n"),
+		Fields:  fields,
+	}
+	var ok bool
+	r.PC, r.File, r.Line, ok = runtime.Caller(calldepth)
+	if !ok {
+		r.File = "???"
+		r.Line = 0
+	}
+	return r
+}
+
+// file name without the directory, the same as log.Lshortfile
+func (r *Record) ShortFile() string {
+	if i := strings.LastIndexByte(r.File, '/'); i >= 0 {
+		return r.File[i+1:]
+	}
+	return r.File
+}
@@ -1,57 +1,115 @@
 package logging
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"path"
+	"strings"
 	"sync"
 )
 
 type ReplaceFunction func(Logger)
 
+// LoggerFactory creates the logger Register adds for a new name
+type LoggerFactory func(level string, name string) Logger
+
+func defaultLoggerFactory(level string, name string) Logger {
+	return NewStd2Logger3(level, name)
+}
+
+var loggerFactory LoggerFactory = defaultLoggerFactory
+
 var registry map[string]Logger
 
 var replacefunction map[string]ReplaceFunction
 
 var logOutput io.Writer
-var outMux *sync.Mutex
+var logEncoder Encoder
+
+// guards the registry and all package level configuration
+var outMux *sync.RWMutex
 
 func init() {
 	registry = make(map[string]Logger)
 	replacefunction = make(map[string]ReplaceFunction)
 	logOutput = os.Stderr
-	outMux = &sync.Mutex{}
+	outMux = &sync.RWMutex{}
+
+	// levels from the environment, bad entries are reported and skipped
+	if err := ApplyEnv(); err != nil {
+		fmt.Fprintf(os.Stderr, "go-logging: %s\n", err)
+	}
 }
 
 func AddLogger(name string, log Logger, replacefunc ReplaceFunction) {
 	Dbgf("AddLogger name=%s log=%#v replacefunc=%#v\n", name, log, replacefunc)
 
-	if _, found := registry[name]; found {
-		panic(fmt.Sprintf("AddLogger: Existing logger found", name))
+	if _, added := addLogger(name, log, replacefunc); !added {
+		panic(fmt.Sprintf("AddLogger: Existing logger found: %s", name))
 	}
+	applyRegisteredLevel(name, log)
+}
+
+// add log unless name is already registered, returns the registered logger and
+// whether log was added
+func addLogger(name string, log Logger, replacefunc ReplaceFunction) (Logger, bool) {
 	outMux.Lock()
-	log.SetWriter(logOutput)
+	defer outMux.Unlock()
+	if existing, found := registry[name]; found {
+		return existing, false
+	}
+	log.SetWriter(outputFor(name))
+	if logEncoder != nil {
+		log.SetEncoder(logEncoder)
+	}
 	registry[name] = log
 	replacefunction[name] = replacefunc
-	outMux.Unlock()
+	return log, true
+}
+
+// set a newly registered logger to the level of a rule for it or an ancestor
+func applyRegisteredLevel(name string, log Logger) {
+	if _, isNull := log.(*NullLogger); isNull {
+		return
+	}
+	if _, from := effectiveLevel(name); from != "" {
+		applyEffectiveLevel(name)
+	}
 }
 
+// log becomes the logger registered for name, GetLogger and the functions changing
+// every logger reach it instead of the one it replaces. a logger replaced by
+// DisableLog is kept and still configured until EnableLog puts it back, replacing
+// a disabled logger with anything but a NullLogger enables the name again
 func ReplaceLogger(name string, log Logger) {
 	Dbgf("ReplaceLogger name=%s log=%#v\n", name, log)
 
-	if GetLogger(name) == nil {
-		AddLogger(name, log, nil)
+	if _, added := addLogger(name, log, nil); added {
+		applyRegisteredLevel(name, log)
+	}
+	outMux.Lock()
+	registry[name] = log
+	if _, isNull := log.(*NullLogger); !isNull {
+		// EnableLog would put back the logger replaced here
+		delete(disabled, name)
+		delete(ruleDisabled, name)
+	}
+	replace_function := replacefunction[name]
+	if h, found := handles[name]; found {
+		h.set(log)
 	}
+	outMux.Unlock()
 
-	if replace_function, ok := replacefunction[name]; ok {
+	if replace_function != nil {
 		replace_function(log)
-	} else {
-		fmt.Println("ERROR REPLACING LOGGER:", name)
 	}
 }
 
 func GetLogger(name string) Logger {
+	outMux.RLock()
+	defer outMux.RUnlock()
 	if l, found := registry[name]; found {
 		return l
 	}
@@ -63,73 +121,304 @@ type LogEntry struct {
 	Log  Logger
 }
 
+// the registered loggers by name, see LoggerStates for their effective and explicit levels
 func ListLogger() map[string]Logger {
-	return registry
+	outMux.RLock()
+	defer outMux.RUnlock()
+	loggers := make(map[string]Logger, len(registry))
+	for name, logger := range registry {
+		loggers[name] = logger
+	}
+	return loggers
 }
 
 var defaultLevel = "trace"
 
+func getDefaultLevel() string {
+	outMux.RLock()
+	defer outMux.RUnlock()
+	return defaultLevel
+}
+func setDefaultLevel(level string) {
+	outMux.Lock()
+	defaultLevel = level
+	outMux.Unlock()
+}
+
+// a level rule which disables the logger
+const LevelOff = "off"
+
+// levels by logger name or glob pattern, remembered for loggers registered later
+var levelRules = make(map[string]string)
+
+// remember a level, or LevelOff, for a logger name or glob pattern (as DisableLogs).
+// it is applied to matching loggers when they register
+func SetLevelRule(pattern string, level string) error {
+	if _, err := path.Match(pattern, ""); err != nil {
+		return fmt.Errorf("SetLevelRule: invalid pattern %q: %s", pattern, err)
+	}
+	if err := checkLevel(level, true); err != nil {
+		return fmt.Errorf("SetLevelRule: %s", err)
+	}
+	outMux.Lock()
+	levelRules[pattern] = level
+	outMux.Unlock()
+	return nil
+}
+
+// the level rule for name, an exact name wins over patterns and a longer pattern
+// wins over a shorter one
+func ruleLevel(name string) (string, bool) {
+	outMux.RLock()
+	defer outMux.RUnlock()
+	if pattern, found := bestMatch(levelRules, name); found {
+		return levelRules[pattern], true
+	}
+	return "", false
+}
+
+// the key of rules for name, an exact name wins over patterns and a longer pattern
+// wins over a shorter one
+func bestMatch[T any](rules map[string]T, name string) (string, bool) {
+	if _, found := rules[name]; found {
+		return name, true
+	}
+	best := ""
+	for pattern := range rules {
+		if matched, _ := path.Match(pattern, name); !matched {
+			continue
+		}
+		if len(pattern) > len(best) || (len(pattern) == len(best) && pattern < best) {
+			best = pattern
+		}
+	}
+	return best, best != ""
+}
+
+// the parent of a hierarchical name, "db.pool" and "db/pool" are children of "db"
+func parentName(name string) string {
+	i := strings.LastIndexAny(name, "./")
+	if i < 0 {
+		return ""
+	}
+	return name[:i]
+}
+
+// name or one of its ancestors is pattern or matches it as a glob
+func underPattern(name string, pattern string) bool {
+	for n := name; n != ""; n = parentName(n) {
+		if matched, _ := path.Match(pattern, n); matched || n == pattern {
+			return true
+		}
+	}
+	return false
+}
+
+// the level of name from its own rule or the nearest ancestor with one, from is the
+// name which had the rule. without any the default level is returned and from is empty
+func effectiveLevel(name string) (level string, from string) {
+	for n := name; n != ""; n = parentName(n) {
+		if level, found := ruleLevel(n); found {
+			return level, n
+		}
+	}
+	return getDefaultLevel(), ""
+}
+
+// set a registered logger to its effective level, disabling it for LevelOff
+func applyEffectiveLevel(name string) {
+	level, _ := effectiveLevel(name)
+	if level == LevelOff {
+		if !IsDisabled(name) {
+			disableByRule(name)
+		}
+		return
+	}
+	enableByRule(name)
+	setLevel(name, level)
+}
+
+// loggers disabled by an "off" level rule, enabled again when a later config drops it
+var ruleDisabled = make(map[string]bool)
+
+func disableByRule(name string) {
+	DisableLog(name)
+	outMux.Lock()
+	ruleDisabled[name] = true
+	outMux.Unlock()
+}
+
+// enable a logger disabled by a rule, a logger disabled with DisableLog stays disabled
+func enableByRule(name string) {
+	outMux.Lock()
+	found := ruleDisabled[name]
+	delete(ruleDisabled, name)
+	outMux.Unlock()
+	if found {
+		EnableLog(name)
+	}
+}
+
+// loggers replaced by DisableLog, kept so EnableLog can put them back
+var disabled = make(map[string]Logger)
+
 func DisableLog(name string) {
+	outMux.Lock()
+	if log, found := registry[name]; found {
+		if _, isNull := log.(*NullLogger); !isNull {
+			disabled[name] = log
+		}
+	}
+	outMux.Unlock()
 	ReplaceLogger(name, NewNullLogger())
 }
 func DisableLogs(pattern string) {
-	for name, _ := range registry {
+	for name, _ := range ListLogger() {
 		if matched, _ := path.Match(pattern, name); matched {
-			ReplaceLogger(name, NewNullLogger())
+			DisableLog(name)
 		}
 	}
 }
 func DisableAllLogs() {
-	for name, _ := range registry {
+	for name, _ := range ListLogger() {
 		println("** REPLACING ", name)
-		ReplaceLogger(name, NewNullLogger())
+		DisableLog(name)
 	}
 }
 
+// put back a logger disabled with DisableLog, returns false if it was not disabled
+func EnableLog(name string) bool {
+	outMux.Lock()
+	log, found := disabled[name]
+	delete(disabled, name)
+	outMux.Unlock()
+	if !found {
+		return false
+	}
+	ReplaceLogger(name, log)
+	return true
+}
+func EnableLogs(pattern string) {
+	for name, _ := range ListLogger() {
+		if matched, _ := path.Match(pattern, name); matched {
+			EnableLog(name)
+		}
+	}
+}
+
+func IsDisabled(name string) bool {
+	outMux.RLock()
+	defer outMux.RUnlock()
+	_, found := disabled[name]
+	return found
+}
+
+// the logger DisableLog replaced, nil when name is not disabled
+func disabledLogger(name string) Logger {
+	outMux.RLock()
+	defer outMux.RUnlock()
+	return disabled[name]
+}
+
+// level changes also go to a disabled logger so it comes back at the new level
+func setDisabledLevel(name string, level string) {
+	if log := disabledLogger(name); log != nil {
+		log.SetLevel(level)
+	}
+}
+
+// set every logger to level, also the default for loggers registered later. levels
+// set by name or pattern are forgotten, see SetDefaultLevel to keep them
 func SetLogLevel(level string) {
+	outMux.Lock()
 	defaultLevel = level
-	for _, logger := range registry {
+	levelRules = make(map[string]string)
+	outMux.Unlock()
+	for name, logger := range ListLogger() {
+		// an "off" rule is gone too
+		enableByRule(name)
 		logger.SetLevel(level)
+		setDisabledLevel(name, level)
 	}
 }
-func SetLevel(name string, level string) {
+
+// set the level of loggers with no level of their own or of an ancestor
+func SetDefaultLevel(level string) {
+	setDefaultLevel(level)
+	for name := range ListLogger() {
+		if _, from := effectiveLevel(name); from == "" {
+			setLevel(name, level)
+		}
+	}
+}
+
+func setLevel(name string, level string) {
 	log := GetLogger(name)
 	if log != nil {
 		log.SetLevel(level)
+		setDisabledLevel(name, level)
 	}
 }
-func SetLogLevels(levelMap map[string]string) {
-	var log Logger
+
+// set the level of name and of its descendants which have no level of their own,
+// eg. "db" also sets "db.pool" and "db/pool". name may be a glob pattern (as
+// DisableLogs). the level is remembered when name is not registered yet
+func SetLevel(name string, level string) error {
+	if err := SetLevelRule(name, level); err != nil {
+		return err
+	}
+	for n := range ListLogger() {
+		if underPattern(n, name) {
+			applyEffectiveLevel(n)
+		}
+	}
+	return nil
+}
+
+// set the levels of names or patterns as SetLevel, invalid entries are skipped and
+// returned as the error
+func SetLogLevels(levelMap map[string]string) error {
+	var errs []error
 	for name, level := range levelMap {
-		log = GetLogger(name)
-		if log != nil {
-			log.SetLevel(level)
-		} else {
-			println("*** Logger not found: ", name, " cannot set level ***")
+		if err := SetLevel(name, level); err != nil {
+			errs = append(errs, err)
 		}
 	}
+	return errors.Join(errs...)
 }
 
+// print each logger with its level, and where the level comes from when it is not
+// set on the logger itself
 func PrintLoggers() {
 	fmt.Printf("Registered Loggers:\n")
-	for name, logger := range registry {
-		fmt.Printf("%s: %s\n", name, logger.GetLevel())
+	for _, s := range LoggerStates() {
+		switch {
+		case s.Disabled:
+			fmt.Printf("%s: %s (disabled)\n", s.Name, s.Level)
+		case s.Explicit != "":
+			fmt.Printf("%s: %s\n", s.Name, s.Level)
+		case s.InheritedFrom != "":
+			fmt.Printf("%s: %s (inherited from %s)\n", s.Name, s.Level, s.InheritedFrom)
+		default:
+			fmt.Printf("%s: %s (default)\n", s.Name, s.Level)
+		}
 	}
 }
 
 func SetLogLevelsWithDefault(level string, levelMap map[string]string) {
 	var to_set string
 	var found bool
-	for name, logger := range registry {
+	for name, logger := range ListLogger() {
 		to_set, found = levelMap[name]
 		if !found {
-			logger.SetLevel(level)
-		} else {
-			logger.SetLevel(to_set)
+			to_set = level
 		}
+		logger.SetLevel(to_set)
+		setDisabledLevel(name, to_set)
 	}
 }
 
+// set the output of every logger without a route of its own, see SetLoggerOutput
 func SetLogOutput(out io.Writer) error {
 	outMux.Lock()
 	if out == nil {
@@ -138,23 +427,147 @@ func SetLogOutput(out io.Writer) error {
 		logOutput = out
 	}
 	outMux.Unlock()
-	for _, logger := range registry {
-		logger.SetWriter(logOutput)
+	for name := range ListLogger() {
+		applyOutput(name)
 	}
 	return nil
 }
 
+// outputs by logger name or glob pattern, remembered for loggers registered later
+var outputRoutes = make(map[string]io.Writer)
+
+// route the loggers named name or matching a glob pattern (as DisableLogs) and their
+// descendants to out instead of the SetLogOutput writer, nil removes the route.
+// the route also applies to loggers registered later
+//
+//	f, err := gologging.NewReopenableFile("/var/log/audit.log", 0600)
+//	gologging.SetLoggerOutput("audit", f)
+//	gologging.SetLoggerOutput("http.access", os.Stdout)
+func SetLoggerOutput(pattern string, out io.Writer) error {
+	if _, err := path.Match(pattern, ""); err != nil {
+		return fmt.Errorf("SetLoggerOutput: invalid pattern %q: %s", pattern, err)
+	}
+	outMux.Lock()
+	if out == nil {
+		delete(outputRoutes, pattern)
+	} else {
+		outputRoutes[pattern] = out
+	}
+	outMux.Unlock()
+	for name := range ListLogger() {
+		applyOutput(name)
+	}
+	return nil
+}
+
+// the writer for name from the route for it or its nearest ancestor, else the
+// SetLogOutput writer. guarded by outMux
+func outputFor(name string) io.Writer {
+	for n := name; n != ""; n = parentName(n) {
+		if pattern, found := bestMatch(outputRoutes, n); found {
+			return outputRoutes[pattern]
+		}
+	}
+	return logOutput
+}
+
+// set the writer of a registered logger, and of the original when it is disabled
+func applyOutput(name string) {
+	outMux.RLock()
+	out := outputFor(name)
+	log := registry[name]
+	orig := disabled[name]
+	outMux.RUnlock()
+	if log != nil {
+		log.SetWriter(out)
+	}
+	if orig != nil {
+		orig.SetWriter(out)
+	}
+}
+
+// set the output format of every registered logger and any registered later,
+// nil restores each loggers default format
+func SetLogEncoder(encoder Encoder) {
+	outMux.Lock()
+	logEncoder = encoder
+	outMux.Unlock()
+	for name, logger := range ListLogger() {
+		logger.SetEncoder(encoder)
+		if orig := disabledLogger(name); orig != nil {
+			orig.SetEncoder(encoder)
+		}
+	}
+}
+
+// set the output format of every registered logger by name, see NewEncoder
+func SetLogFormat(format string) error {
+	encoder, err := NewEncoder(format)
+	if err != nil {
+		return err
+	}
+	SetLogEncoder(encoder)
+	return nil
+}
+
+// set the output format of a single registered logger
+func SetFormat(name string, format string) error {
+	encoder, err := NewEncoder(format)
+	if err != nil {
+		return err
+	}
+	log := GetLogger(name)
+	if log == nil {
+		return fmt.Errorf("SetFormat: logger not found: %s", name)
+	}
+	log.SetEncoder(encoder)
+	if orig := disabledLogger(name); orig != nil {
+		orig.SetEncoder(encoder)
+	}
+	return nil
+}
+
+// set the function Register uses to create loggers, nil restores the default Std2Logger
+func SetLoggerFactory(factory LoggerFactory) {
+	outMux.Lock()
+	defer outMux.Unlock()
+	if factory == nil {
+		factory = defaultLoggerFactory
+	}
+	loggerFactory = factory
+}
+
+// flush the output of every registered logger
+func FlushAll() {
+	for _, logger := range ListLogger() {
+		logger.Flush()
+	}
+}
+
 // register a logger name
 // if the name is not found, we use the standard logger
+//
+// the returned *Handle always writes through the logger registered for name, so
+// replacefunc may be nil. when given it is still called on every replacement
 func Register(name string, replacefunc ReplaceFunction) (log Logger) {
-	if replacefunc == nil {
-		panic("You can't use nil replacefunc!")
+	if GetLogger(name) == nil {
+		createLogger(name, replacefunc)
 	}
-	log = GetLogger(name)
-	if log == nil {
-		// add a new standard logger at level trace until configured otherwise..
-		log = NewStd2Logger3(defaultLevel, name)
-		AddLogger(name, log, replacefunc)
+	outMux.Lock()
+	defer outMux.Unlock()
+	return handleFor(name, registry[name])
+}
+
+// add a logger made by the logger factory at the default level until configured otherwise
+func createLogger(name string, replacefunc ReplaceFunction) Logger {
+	outMux.RLock()
+	factory := loggerFactory
+	level := defaultLevel
+	outMux.RUnlock()
+	// another goroutine may register the same name first, theirs is used
+	if log, added := addLogger(name, factory(level, name), replacefunc); added {
+		applyRegisteredLevel(name, log)
 	}
-	return log
+	// a level rule may have disabled it already
+	return GetLogger(name)
 }
@@ -0,0 +1,160 @@
+package logging
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+// reconfigure the registry from several goroutines while others log, run with -race
+func TestRegistryConcurrentReconfigure(t *testing.T) {
+	SetLogOutput(io.Discard)
+	defer SetLogOutput(nil)
+	defer ApplyConfig(&Config{})
+
+	names := []string{"stress", "stress.db", "stress.db.pool", "stress/http", "stress.cache"}
+	logfile := filepath.Join(t.TempDir(), "stress.log")
+
+	var wg sync.WaitGroup
+	run := func(fn func(i int)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 200; i++ {
+				fn(i)
+			}
+		}()
+	}
+
+	for _, name := range names {
+		name := name
+		run(func(i int) {
+			log := Register(name, nil)
+			log.Infof("message %d", i)
+			log.With("i", i).Debug("with")
+			log.IsTrace()
+			log.GetLevel()
+		})
+	}
+	run(func(i int) {
+		Register(fmt.Sprintf("stress.new%d", i%20), func(Logger) {})
+	})
+	run(func(i int) {
+		SetLevel(names[i%len(names)], []string{"trace", "debug", "info", "error"}[i%4])
+		SetLogLevels(map[string]string{"stress.*": "warning"})
+	})
+	run(func(i int) {
+		name := names[i%len(names)]
+		if i%2 == 0 {
+			DisableLog(name)
+		} else {
+			EnableLog(name)
+		}
+		DisableLogs("stress.new*")
+		EnableLogs("stress.new*")
+	})
+	run(func(i int) {
+		if i%2 == 0 {
+			SetLogOutput(io.Discard)
+		} else {
+			SetLoggerOutput("stress.db", io.Discard)
+		}
+	})
+	run(func(i int) {
+		SetLogFormat([]string{"text", "json", "logfmt"}[i%3])
+	})
+	run(func(i int) {
+		c := &Config{
+			Level:  "info",
+			Levels: map[string]string{"stress.db": "debug", "stress.cache": LevelOff},
+		}
+		if i%10 == 0 {
+			c.Outputs = []OutputConfig{{Type: "file", Path: logfile}}
+		}
+		if err := ApplyConfig(c); err != nil {
+			t.Error(err)
+		}
+	})
+	run(func(i int) {
+		ListLogger()
+		LoggerStates()
+		CurrentConfig()
+	})
+	wg.Wait()
+
+	for _, name := range names {
+		EnableLog(name)
+		if GetLogger(name) == nil {
+			t.Errorf("%s: not registered", name)
+		}
+	}
+}
+
+// a handle keeps working while its logger is replaced
+func TestHandleConcurrentReplace(t *testing.T) {
+	SetLogOutput(io.Discard)
+	defer SetLogOutput(nil)
+
+	log := Register("stress.handle", nil)
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 500; i++ {
+				log.Info("message", i)
+				log.Errorf("error %d", i)
+			}
+		}()
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 500; i++ {
+			ReplaceLogger("stress.handle", NewStd2Logger3("info", "stress.handle"))
+			DisableLog("stress.handle")
+			EnableLog("stress.handle")
+		}
+	}()
+	wg.Wait()
+}
+
+// SetLogLevel forgets levels set by name, so later children do not inherit them
+func TestSetLogLevelClearsRules(t *testing.T) {
+	defer SetLogLevel("trace")
+	Register("sdb", nil)
+	Register("sdb.old", nil)
+	if err := SetLevel("sdb", "debug"); err != nil {
+		t.Fatal(err)
+	}
+	SetLogLevel("error")
+	Register("sdb.new", nil)
+
+	for _, s := range LoggerStates() {
+		if s.Name != "sdb" && s.Name != "sdb.old" && s.Name != "sdb.new" {
+			continue
+		}
+		if s.Level != "ERROR" || s.Explicit != "" || s.InheritedFrom != "" {
+			t.Errorf("%s: %+v, want ERROR from the default", s.Name, s)
+		}
+	}
+}
+
+// a replacement of a disabled logger stays installed
+func TestReplaceDisabledLogger(t *testing.T) {
+	Register("replaced", nil)
+	DisableLog("replaced")
+	replacement := NewStd2Logger3("info", "replaced")
+	ReplaceLogger("replaced", replacement)
+	if IsDisabled("replaced") {
+		t.Error("still disabled after ReplaceLogger")
+	}
+	if EnableLog("replaced") {
+		t.Error("EnableLog put back a logger")
+	}
+	if GetLogger("replaced") != Logger(replacement) {
+		t.Error("the replacement is not installed")
+	}
+}
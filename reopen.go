@@ -0,0 +1,158 @@
+package logging
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+)
+
+// Reopener is a file sink which can close and reopen its path, eg. after logrotate
+// moved the file away
+type Reopener interface {
+	Reopen() error
+}
+
+var reopenMux sync.Mutex
+var reopeners = make(map[Reopener]struct{})
+
+// add a sink to the set reopened by ReopenAll, file sinks in this package add themselves
+func RegisterReopener(r Reopener) {
+	reopenMux.Lock()
+	reopeners[r] = struct{}{}
+	reopenMux.Unlock()
+}
+
+func UnregisterReopener(r Reopener) {
+	reopenMux.Lock()
+	delete(reopeners, r)
+	reopenMux.Unlock()
+}
+
+// reopen every registered file sink
+func ReopenAll() error {
+	reopenMux.Lock()
+	list := make([]Reopener, 0, len(reopeners))
+	for r := range reopeners {
+		list = append(list, r)
+	}
+	reopenMux.Unlock()
+
+	var errs []error
+	for _, r := range list {
+		if err := r.Reopen(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// HandleSIGHUP calls ReopenAll whenever the process receives SIGHUP,
+// call stop to remove the handler
+func HandleSIGHUP() (stop func()) {
+	ch := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(ch, syscall.SIGHUP)
+	go func() {
+		for {
+			select {
+			case <-ch:
+				if err := ReopenAll(); err != nil {
+					fmt.Fprintf(os.Stderr, "go-logging: reopen: %s\n", err)
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			signal.Stop(ch)
+			close(done)
+		})
+	}
+}
+
+// ReopenableFile is an io.Writer appending to path which can be reopened without
+// losing or interleaving lines, for use with logrotate's create or move strategy
+//
+//	f, err := gologging.NewReopenableFile("/var/log/app.log", 0644)
+//	gologging.SetLogOutput(f)
+//	gologging.HandleSIGHUP()
+type ReopenableFile struct {
+	mu     sync.Mutex
+	path   string
+	perm   os.FileMode
+	file   *os.File
+	closed bool
+}
+
+func NewReopenableFile(path string, perm os.FileMode) (*ReopenableFile, error) {
+	f := &ReopenableFile{path: path, perm: perm}
+	file, err := f.open()
+	if err != nil {
+		return nil, err
+	}
+	f.file = file
+	RegisterReopener(f)
+	return f, nil
+}
+
+func (f *ReopenableFile) open() (*os.File, error) {
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, f.perm)
+	if err != nil {
+		return nil, fmt.Errorf("ReopenableFile: %s", err)
+	}
+	return file, nil
+}
+
+func (f *ReopenableFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.closed {
+		return 0, os.ErrClosed
+	}
+	return f.file.Write(p)
+}
+
+// Reopen opens path again and then closes the previous file, on error the
+// previous file is kept
+func (f *ReopenableFile) Reopen() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.closed {
+		return os.ErrClosed
+	}
+	file, err := f.open()
+	if err != nil {
+		return err
+	}
+	old := f.file
+	f.file = file
+	old.Close()
+	return nil
+}
+
+// Flush commits the file to disk
+func (f *ReopenableFile) Flush() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.closed {
+		return nil
+	}
+	return f.file.Sync()
+}
+
+func (f *ReopenableFile) Close() error {
+	UnregisterReopener(f)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.closed {
+		return nil
+	}
+	f.closed = true
+	return f.file.Close()
+}
@@ -0,0 +1,353 @@
+package logging
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+type RotateInterval int
+
+const (
+	RotateNever RotateInterval = iota
+	RotateHourly
+	RotateDaily
+)
+
+// timestamp appended to rotated files, sorts in time order
+const rotateTimeFormat = "20060102-150405"
+
+type RotateOptions struct {
+	// rotate once the file would grow beyond MaxSize bytes, 0 disables
+	MaxSize int64
+	// rotate on hour or day boundaries in local time
+	Interval RotateInterval
+	// gzip rotated files in the background
+	Compress bool
+
+	// retention of rotated files, a zero value disables each check
+	MaxBackups   int
+	MaxAge       time.Duration
+	MaxTotalSize int64
+
+	// defaults to 0644
+	Perm os.FileMode
+}
+
+// RotatingFile is an io.Writer appending to a file which is rotated on size and/or
+// time. rotated files are named path.YYYYMMDD-HHMMSS, with .gz when compressed.
+// it is safe to share between all registered loggers
+//
+//	f, err := gologging.NewRotatingFile("/var/log/app.log", gologging.RotateOptions{MaxSize: 100 << 20, Compress: true, MaxBackups: 10})
+//	gologging.SetLogOutput(f)
+type RotatingFile struct {
+	mu     sync.Mutex
+	path   string
+	opts   RotateOptions
+	file   *os.File
+	size   int64
+	next   time.Time
+	closed bool
+
+	// wakes the goroutine compressing and removing rotated files
+	mill chan struct{}
+	done chan struct{}
+	wg   sync.WaitGroup
+}
+
+func NewRotatingFile(path string, opts RotateOptions) (*RotatingFile, error) {
+	if opts.Perm == 0 {
+		opts.Perm = 0644
+	}
+	f := &RotatingFile{
+		path: path,
+		opts: opts,
+		mill: make(chan struct{}, 1),
+		done: make(chan struct{}),
+	}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	RegisterReopener(f)
+	f.wg.Add(1)
+	go f.millRun()
+	// clean up anything left over from a previous run
+	f.wakeMill()
+	return f, nil
+}
+
+func (f *RotatingFile) open() error {
+	if err := os.MkdirAll(filepath.Dir(f.path), 0755); err != nil {
+		return fmt.Errorf("RotatingFile: %s", err)
+	}
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, f.opts.Perm)
+	if err != nil {
+		return fmt.Errorf("RotatingFile: %s", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("RotatingFile: %s", err)
+	}
+	f.file = file
+	f.size = info.Size()
+	f.next = nextRotation(time.Now(), f.opts.Interval)
+	return nil
+}
+
+// the next hour or day boundary after now, zero when rotating on time is disabled
+func nextRotation(now time.Time, interval RotateInterval) time.Time {
+	y, m, d := now.Date()
+	switch interval {
+	case RotateHourly:
+		return time.Date(y, m, d, now.Hour()+1, 0, 0, 0, now.Location())
+	case RotateDaily:
+		return time.Date(y, m, d+1, 0, 0, 0, 0, now.Location())
+	}
+	return time.Time{}
+}
+
+func (f *RotatingFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.closed {
+		return 0, os.ErrClosed
+	}
+	var rotateErr error
+	if f.shouldRotate(int64(len(p))) {
+		// when rotating fails the line still goes to the file at path
+		rotateErr = f.rotate()
+	}
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	if err == nil {
+		err = rotateErr
+	}
+	return n, err
+}
+
+func (f *RotatingFile) shouldRotate(n int64) bool {
+	if f.opts.MaxSize > 0 && f.size > 0 && f.size+n > f.opts.MaxSize {
+		return true
+	}
+	return !f.next.IsZero() && !time.Now().Before(f.next)
+}
+
+// Rotate closes the current file, renames it and starts a new one
+func (f *RotatingFile) Rotate() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.closed {
+		return os.ErrClosed
+	}
+	return f.rotate()
+}
+
+// a failed rename opens path again, so writes carry on in the current file and
+// the next write tries to rotate again
+func (f *RotatingFile) rotate() error {
+	// the file is closed first as an open file can not be renamed everywhere
+	f.file.Close()
+	name := f.backupName(time.Now())
+	if err := os.Rename(f.path, name); err != nil && !os.IsNotExist(err) {
+		return errors.Join(fmt.Errorf("RotatingFile: %s", err), f.open())
+	}
+	if err := f.open(); err != nil {
+		return err
+	}
+	f.wakeMill()
+	return nil
+}
+
+// unique name for a rotated file, a counter is added when rotating more than once a second
+func (f *RotatingFile) backupName(t time.Time) string {
+	base := f.path + "." + t.Format(rotateTimeFormat)
+	name := base
+	for i := 1; ; i++ {
+		_, err := os.Stat(name)
+		_, gzerr := os.Stat(name + ".gz")
+		if os.IsNotExist(err) && os.IsNotExist(gzerr) {
+			return name
+		}
+		name = fmt.Sprintf("%s.%d", base, i)
+	}
+}
+
+// Reopen opens path again, for when the file was moved away by something else
+func (f *RotatingFile) Reopen() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.closed {
+		return os.ErrClosed
+	}
+	old := f.file
+	if err := f.open(); err != nil {
+		return err
+	}
+	old.Close()
+	return nil
+}
+
+// Flush commits the current file to disk
+func (f *RotatingFile) Flush() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.closed {
+		return nil
+	}
+	return f.file.Sync()
+}
+
+// Close flushes and closes the file and waits for background compression to finish
+func (f *RotatingFile) Close() error {
+	UnregisterReopener(f)
+	f.mu.Lock()
+	if f.closed {
+		f.mu.Unlock()
+		return nil
+	}
+	f.closed = true
+	f.file.Sync()
+	err := f.file.Close()
+	close(f.done)
+	f.mu.Unlock()
+	f.wg.Wait()
+	return err
+}
+
+func (f *RotatingFile) wakeMill() {
+	select {
+	case f.mill <- struct{}{}:
+	default:
+	}
+}
+
+func (f *RotatingFile) millRun() {
+	defer f.wg.Done()
+	for {
+		select {
+		case <-f.mill:
+			f.millOnce()
+		case <-f.done:
+			// finish anything rotated just before Close
+			select {
+			case <-f.mill:
+				f.millOnce()
+			default:
+			}
+			return
+		}
+	}
+}
+
+type backupFile struct {
+	path string
+	info os.FileInfo
+}
+
+// rotated files, newest first
+func (f *RotatingFile) backups() ([]backupFile, error) {
+	dir, prefix := filepath.Dir(f.path), filepath.Base(f.path)+"."
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var files []backupFile
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		stamp := strings.TrimPrefix(name, prefix)
+		if len(stamp) < len(rotateTimeFormat) {
+			continue
+		}
+		if _, err := time.Parse(rotateTimeFormat, stamp[:len(rotateTimeFormat)]); err != nil {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		files = append(files, backupFile{filepath.Join(dir, name), info})
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].info.ModTime().After(files[j].info.ModTime())
+	})
+	return files, nil
+}
+
+// compress rotated files then enforce retention
+func (f *RotatingFile) millOnce() {
+	files, err := f.backups()
+	if err != nil {
+		Dbgf("RotatingFile: %s\n", err)
+		return
+	}
+	if f.opts.Compress {
+		for i, b := range files {
+			if strings.HasSuffix(b.path, ".gz") {
+				continue
+			}
+			if err := gzipFile(b.path); err != nil {
+				Dbgf("RotatingFile: compress %s: %s\n", b.path, err)
+				continue
+			}
+			if info, err := os.Stat(b.path + ".gz"); err == nil {
+				files[i] = backupFile{b.path + ".gz", info}
+			}
+		}
+	}
+
+	var total int64
+	cutoff := time.Now().Add(-f.opts.MaxAge)
+	for i, b := range files {
+		total += b.info.Size()
+		remove := (f.opts.MaxBackups > 0 && i >= f.opts.MaxBackups) ||
+			(f.opts.MaxAge > 0 && b.info.ModTime().Before(cutoff)) ||
+			(f.opts.MaxTotalSize > 0 && total > f.opts.MaxTotalSize)
+		if remove {
+			if err := os.Remove(b.path); err != nil {
+				Dbgf("RotatingFile: remove %s: %s\n", b.path, err)
+			}
+		}
+	}
+}
+
+// gzip path into path.gz and remove path, keeping its modification time
+func gzipFile(path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	info, err := src.Stat()
+	if err != nil {
+		return err
+	}
+	dst, err := os.OpenFile(path+".gz", os.O_CREATE|os.O_TRUNC|os.O_WRONLY, info.Mode())
+	if err != nil {
+		return err
+	}
+	gz := gzip.NewWriter(dst)
+	_, err = io.Copy(gz, src)
+	if err == nil {
+		err = gz.Close()
+	}
+	if cerr := dst.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(path + ".gz")
+		return err
+	}
+	os.Chtimes(path+".gz", info.ModTime(), info.ModTime())
+	return os.Remove(path)
+}
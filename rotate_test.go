@@ -0,0 +1,210 @@
+package logging
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"sort"
+	"testing"
+	"time"
+)
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+// rotated files in dir, oldest first
+func rotated(t *testing.T, f *RotatingFile) []string {
+	t.Helper()
+	files, err := f.backups()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, b := range files {
+		names = append(names, b.path)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestRotateSize(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	f, err := NewRotatingFile(path, RotateOptions{MaxSize: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{"line one\n", "line two\n", "line three\n"} {
+		if _, err := f.Write([]byte(line)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	f.Close()
+
+	if got := readFile(t, path); got != "line three\n" {
+		t.Errorf("current file: %q", got)
+	}
+	names := rotated(t, f)
+	if len(names) != 2 {
+		t.Fatalf("rotated files: %v", names)
+	}
+	if got := readFile(t, names[0]) + readFile(t, names[1]); got != "line one\nline two\n" {
+		t.Errorf("rotated: %q", got)
+	}
+}
+
+// rotating twice in the same second adds a counter, which skips compressed files
+func TestRotateBackupName(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	f := &RotatingFile{path: path}
+	now := time.Date(2024, 5, 1, 10, 30, 0, 0, time.Local)
+	base := path + ".20240501-103000"
+
+	for _, want := range []string{base, base + ".1", base + ".2"} {
+		name := f.backupName(now)
+		if name != want {
+			t.Fatalf("got %s, want %s", name, want)
+		}
+		if name == base+".1" {
+			name += ".gz"
+		}
+		if err := os.WriteFile(name, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestRotateRetention(t *testing.T) {
+	// backups of 10 bytes, newest first
+	ages := []time.Duration{time.Minute, 2 * time.Minute, 2 * time.Hour, 3 * time.Hour}
+	tests := []struct {
+		name string
+		opts RotateOptions
+		kept int
+	}{
+		{"none", RotateOptions{}, 4},
+		{"MaxBackups", RotateOptions{MaxBackups: 3}, 3},
+		{"MaxAge", RotateOptions{MaxAge: time.Hour}, 2},
+		{"MaxTotalSize", RotateOptions{MaxTotalSize: 15}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "app.log")
+			now := time.Now()
+			var want []string
+			for i, age := range ages {
+				name := path + "." + now.Add(-age).Format(rotateTimeFormat)
+				if err := os.WriteFile(name, []byte("123456789\n"), 0644); err != nil {
+					t.Fatal(err)
+				}
+				os.Chtimes(name, now.Add(-age), now.Add(-age))
+				if i < tt.kept {
+					want = append(want, name)
+				}
+			}
+			sort.Strings(want)
+
+			// retention runs when the file is opened, Close waits for it
+			f, err := NewRotatingFile(path, tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			f.Close()
+			if got := rotated(t, f); !slices.Equal(got, want) {
+				t.Errorf("got %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestRotateCompress(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	mtime := time.Now().Add(-time.Hour).Truncate(time.Second)
+	name := path + "." + mtime.Format(rotateTimeFormat)
+	if err := os.WriteFile(name, []byte("rotated\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	os.Chtimes(name, mtime, mtime)
+
+	f, err := NewRotatingFile(path, RotateOptions{Compress: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	if _, err := os.Stat(name); !os.IsNotExist(err) {
+		t.Errorf("uncompressed file left: %v", err)
+	}
+	info, err := os.Stat(name + ".gz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !info.ModTime().Equal(mtime) {
+		t.Errorf("mtime %s, want %s", info.ModTime(), mtime)
+	}
+	gz, err := os.Open(name + ".gz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer gz.Close()
+	r, err := gzip.NewReader(gz)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := io.ReadAll(r); string(b) != "rotated\n" {
+		t.Errorf("decompressed %q", b)
+	}
+}
+
+// stop files in dir being renamed while still letting path be opened for append
+func denyRename(t *testing.T, dir string) {
+	t.Helper()
+	if os.Geteuid() == 0 {
+		// root ignores the directory permissions
+		if err := exec.Command("chattr", "+a", dir).Run(); err != nil {
+			t.Skipf("chattr +a: %s", err)
+		}
+		t.Cleanup(func() { exec.Command("chattr", "-a", dir).Run() })
+		return
+	}
+	if err := os.Chmod(dir, 0555); err != nil {
+		t.Skip(err)
+	}
+	t.Cleanup(func() { os.Chmod(dir, 0755) })
+}
+
+// when the rename fails writes carry on in the file at path
+func TestRotateRenameFails(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	f, err := NewRotatingFile(path, RotateOptions{MaxSize: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	f.Write([]byte("line one\n"))
+	denyRename(t, dir)
+
+	if err := f.Rotate(); err == nil {
+		t.Fatal("Rotate: no error")
+	}
+	// the write rotating fails too, but the line is still written
+	n, err := f.Write([]byte("line two\n"))
+	if err == nil || n != len("line two\n") {
+		t.Errorf("Write: %d, %v", n, err)
+	}
+	if got := readFile(t, path); got != "line one\nline two\n" {
+		t.Errorf("file: %q", got)
+	}
+	if names := rotated(t, f); len(names) != 0 {
+		t.Errorf("rotated files: %v", names)
+	}
+}
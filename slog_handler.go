@@ -0,0 +1,157 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"runtime"
+	"time"
+)
+
+// slog levels beyond the four slog defines
+const (
+	SlogLevelTrace    = slog.LevelDebug - 4
+	SlogLevelCritical = slog.LevelError + 4
+)
+
+// LevelFromSlog maps a slog level onto TRACE..CRITICAL
+func LevelFromSlog(level slog.Level) int {
+	switch {
+	case level < slog.LevelDebug:
+		return TRACE
+	case level < slog.LevelInfo:
+		return DEBUG
+	case level < slog.LevelWarn:
+		return INFO
+	case level < slog.LevelError:
+		return WARNING
+	case level < SlogLevelCritical:
+		return ERROR
+	}
+	return CRITICAL
+}
+
+// SlogLevel maps TRACE..CRITICAL onto a slog level
+func SlogLevel(level int) slog.Level {
+	switch {
+	case level >= CRITICAL:
+		return SlogLevelCritical
+	case level >= ERROR:
+		return slog.LevelError
+	case level >= WARNING:
+		return slog.LevelWarn
+	case level >= INFO:
+		return slog.LevelInfo
+	case level >= DEBUG:
+		return slog.LevelDebug
+	}
+	return SlogLevelTrace
+}
+
+// SlogHandler is a slog.Handler writing to a registered logger, so levels set with
+// SetLogLevel, SetLevel and friends apply to slog output too
+//
+//	slog.SetDefault(slog.New(gologging.NewSlogHandler("whatever")))
+type SlogHandler struct {
+	name   string
+	fields []Field
+	group  string
+}
+
+// name is registered as Register does when it is not yet, so its level and output
+// come from the registry
+func NewSlogHandler(name string) *SlogHandler {
+	if GetLogger(name) == nil {
+		createLogger(name, nil)
+	}
+	return &SlogHandler{name: name}
+}
+
+// the registered logger is looked up on every call so ReplaceLogger and DisableLog take effect
+func (h *SlogHandler) logger() Logger {
+	if l := GetLogger(h.name); l != nil {
+		return l
+	}
+	return Std
+}
+
+func (h *SlogHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return isEnabled(h.logger(), LevelFromSlog(level))
+}
+
+func (h *SlogHandler) Handle(ctx context.Context, sr slog.Record) error {
+	r := &Record{
+		Time:    sr.Time,
+		Level:   LevelFromSlog(sr.Level),
+		PC:      sr.PC,
+		Message: sr.Message,
+	}
+	if r.Time.IsZero() {
+		r.Time = time.Now()
+	}
+	r.File, r.Line = "???", 0
+	if sr.PC != 0 {
+		frame, _ := runtime.CallersFrames([]uintptr{sr.PC}).Next()
+		r.File, r.Line = frame.File, frame.Line
+	}
+	fields := make([]Field, 0, len(h.fields)+sr.NumAttrs())
+	fields = append(fields, ContextFields(ctx)...)
+	fields = append(fields, h.fields...)
+	sr.Attrs(func(a slog.Attr) bool {
+		fields = appendAttr(fields, h.group, a)
+		return true
+	})
+	r.Fields = fields
+	return logRecord(h.logger(), r)
+}
+
+func (h *SlogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	h2 := *h
+	h2.fields = make([]Field, 0, len(h.fields)+len(attrs))
+	h2.fields = append(h2.fields, h.fields...)
+	for _, a := range attrs {
+		h2.fields = appendAttr(h2.fields, h.group, a)
+	}
+	return &h2
+}
+
+func (h *SlogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	h2 := *h
+	h2.group = groupKey(h.group, name)
+	return &h2
+}
+
+func groupKey(group, key string) string {
+	if group == "" {
+		return key
+	}
+	return group + "." + key
+}
+
+// flatten an attr into fields, groups become dotted key prefixes
+func appendAttr(fields []Field, group string, a slog.Attr) []Field {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return fields
+	}
+	if a.Value.Kind() == slog.KindGroup {
+		attrs := a.Value.Group()
+		if len(attrs) == 0 {
+			return fields
+		}
+		// an inline group with no key adds its attrs to the current group
+		if a.Key != "" {
+			group = groupKey(group, a.Key)
+		}
+		for _, ga := range attrs {
+			fields = appendAttr(fields, group, ga)
+		}
+		return fields
+	}
+	return append(fields, Field{Key: groupKey(group, a.Key), Value: a.Value.Any()})
+}
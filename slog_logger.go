@@ -0,0 +1,210 @@
+package logging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SlogLogger is a Logger writing through a slog.Handler, eg. slog.NewJSONHandler.
+// the level is kept here so SetLevel and SetLogLevel work as with any other logger,
+// the handler may filter further with its own Enabled
+type SlogLogger struct {
+	CallDepth int
+	mu        sync.RWMutex
+	level     int
+	name      string
+	fields    []Field
+	handler   slog.Handler
+}
+
+func NewSlogLogger(level string, name string, handler slog.Handler) *SlogLogger {
+	lvl, err := LevelFromString(level)
+	if err != nil {
+		panic(fmt.Sprintf("invalid level: %s", level))
+	}
+	return &SlogLogger{
+		CallDepth: 2,
+		level:     lvl,
+		name:      name,
+		handler:   handler,
+	}
+}
+
+// UseSlogHandler makes Register create loggers writing through handler
+func UseSlogHandler(handler slog.Handler) {
+	SetLoggerFactory(func(level string, name string) Logger {
+		return NewSlogLogger(level, name, handler)
+	})
+}
+
+// the handler owns its output and format
+func (l *SlogLogger) SetWriter(io.Writer) {
+}
+func (l *SlogLogger) SetEncoder(Encoder) {
+}
+
+func (l *SlogLogger) Handler() slog.Handler {
+	return l.handler
+}
+
+// returns a child logger sharing the handler with the fields appended
+func (l *SlogLogger) With(keyvals ...interface{}) Logger {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return &SlogLogger{
+		CallDepth: l.CallDepth,
+		level:     l.level,
+		name:      l.name,
+		fields:    appendFields(l.fields, keyvals...),
+		handler:   l.handler,
+	}
+}
+func (l *SlogLogger) WithFields(fields map[string]interface{}) Logger {
+	return l.With(mapToKeyvals(fields)...)
+}
+
+func (l *SlogLogger) GetLevel() string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return Levels[l.level]
+}
+func (l *SlogLogger) setlevel(level int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.level = level
+}
+
+func (l *SlogLogger) enabled(level int) bool {
+	l.mu.RLock()
+	below := l.level > level
+	l.mu.RUnlock()
+	return !below && l.handler.Enabled(context.Background(), SlogLevel(level))
+}
+
+func (l *SlogLogger) output(calldepth int, level int, msg string) error {
+	if !l.enabled(level) {
+		return nil
+	}
+	var pcs [1]uintptr
+	// skip runtime.Callers and output
+	runtime.Callers(calldepth+1, pcs[:])
+	// Sprintln spaces the args as the other loggers do, the handler adds its own newline
+	return l.handle(time.Now(), level, pcs[0], strings.TrimSuffix(msg, "\n"), nil)
+}
+
+func (l *SlogLogger) handle(t time.Time, level int, pc uintptr, msg string, fields []Field) error {
+	sr := slog.NewRecord(t, SlogLevel(level), msg, pc)
+	if l.name != "" {
+		sr.AddAttrs(slog.String("logger", l.name))
+	}
+	for _, f := range l.fields {
+		sr.AddAttrs(slog.Any(f.Key, f.Value))
+	}
+	for _, f := range fields {
+		sr.AddAttrs(slog.Any(f.Key, f.Value))
+	}
+	return l.handler.Handle(context.Background(), sr)
+}
+
+// write a prebuilt record, the handler reports its PC as the source
+func (l *SlogLogger) Log(r *Record) error {
+	if !l.enabled(r.Level) {
+		return nil
+	}
+	return l.handle(r.Time, r.Level, r.PC, r.Message, r.Fields)
+}
+
+// print style functions
+func (l *SlogLogger) Trace(args ...interface{}) {
+	l.output(l.CallDepth, TRACE, fmt.Sprintln(args...))
+}
+func (l *SlogLogger) Debug(args ...interface{}) {
+	l.output(l.CallDepth, DEBUG, fmt.Sprintln(args...))
+}
+func (l *SlogLogger) Info(args ...interface{}) {
+	l.output(l.CallDepth, INFO, fmt.Sprintln(args...))
+}
+func (l *SlogLogger) Warn(args ...interface{}) error {
+	l.output(l.CallDepth, WARNING, fmt.Sprintln(args...))
+	return errors.New(fmt.Sprintln(args...))
+}
+func (l *SlogLogger) Error(args ...interface{}) error {
+	l.output(l.CallDepth, ERROR, fmt.Sprintln(args...))
+	return errors.New(fmt.Sprintln(args...))
+}
+func (l *SlogLogger) Critical(args ...interface{}) error {
+	l.output(l.CallDepth, CRITICAL, fmt.Sprintln(args...))
+	return errors.New(fmt.Sprintln(args...))
+}
+
+// printf style functions
+func (l *SlogLogger) Tracef(format string, args ...interface{}) {
+	l.output(l.CallDepth, TRACE, fmt.Sprintf(format, args...))
+}
+func (l *SlogLogger) Debugf(format string, args ...interface{}) {
+	l.output(l.CallDepth, DEBUG, fmt.Sprintf(format, args...))
+}
+func (l *SlogLogger) Infof(format string, args ...interface{}) {
+	l.output(l.CallDepth, INFO, fmt.Sprintf(format, args...))
+}
+func (l *SlogLogger) Warnf(format string, args ...interface{}) error {
+	l.output(l.CallDepth, WARNING, fmt.Sprintf(format, args...))
+	return fmt.Errorf(format, args...)
+}
+func (l *SlogLogger) Errorf(format string, args ...interface{}) error {
+	l.output(l.CallDepth, ERROR, fmt.Sprintf(format, args...))
+	return fmt.Errorf(format, args...)
+}
+func (l *SlogLogger) Criticalf(format string, args ...interface{}) error {
+	l.output(l.CallDepth, CRITICAL, fmt.Sprintf(format, args...))
+	return fmt.Errorf(format, args...)
+}
+
+// methods to implement the Logger interface
+func (l *SlogLogger) SetLevel(level string) error {
+	lvl, err := LevelFromString(level)
+	if err != nil {
+		return fmt.Errorf("SetLevel: %s", err)
+	}
+	l.setlevel(lvl)
+	return nil
+}
+
+func (l *SlogLogger) Close() {
+}
+func (l *SlogLogger) Closed() bool {
+	return false
+}
+func (l *SlogLogger) Flush() {
+}
+
+func (l *SlogLogger) IsTrace() bool {
+	return l.enabled(TRACE)
+}
+
+func (l *SlogLogger) IsDebug() bool {
+	return l.enabled(DEBUG)
+}
+
+func (l *SlogLogger) IsInfo() bool {
+	return l.enabled(INFO)
+}
+
+func (l *SlogLogger) IsWarn() bool {
+	return l.enabled(WARNING)
+}
+
+func (l *SlogLogger) IsError() bool {
+	return l.enabled(ERROR)
+}
+
+func (l *SlogLogger) IsCritical() bool {
+	return l.enabled(CRITICAL)
+}
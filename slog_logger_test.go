@@ -0,0 +1,24 @@
+package logging
+
+import (
+	"bytes"
+	"log/slog"
+	"testing"
+)
+
+// args are spaced as by the other loggers, with no trailing newline
+func TestSlogLoggerArgs(t *testing.T) {
+	var buf bytes.Buffer
+	l := NewSlogLogger("trace", "slg", slog.NewTextHandler(&buf, &slog.HandlerOptions{
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			return a
+		},
+	}))
+	l.Info("count", 3, "of", 4)
+	if got, want := buf.String(), "level=INFO msg=\"count 3 of 4\" logger=slg\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -1,5 +1,4 @@
 // standard logger interface which inherits the normal log package to provide log levels
-//
 package logging
 
 import (
@@ -15,6 +14,8 @@ type StandardLogger struct {
 	CallDepth int
 	mu        sync.RWMutex
 	level     int
+	fields    []Field
+	out       *output
 	*log.Logger
 }
 
@@ -32,6 +33,7 @@ func NewStandardLogger2(level int) *StandardLogger {
 		Logger:    log.New(os.Stderr, "", log.Lshortfile),
 		CallDepth: 4,
 		level:     level,
+		out:       &output{w: os.Stderr},
 	}
 
 	return l
@@ -42,13 +44,40 @@ func NewStandardLogger3(level, depth int) *StandardLogger {
 		Logger:    log.New(os.Stderr, "", log.Lshortfile),
 		CallDepth: depth,
 		level:     level,
+		out:       &output{w: os.Stderr},
 	}
 
 	return l
 }
 
 func (l *StandardLogger) SetWriter(out io.Writer) {
-	l.Logger = log.New(out, "", log.Lshortfile)
+	l.Logger.SetOutput(out)
+	l.out.mu.Lock()
+	l.out.w = out
+	l.out.mu.Unlock()
+}
+
+// set the output format, nil restores the plain log package layout
+func (l *StandardLogger) SetEncoder(encoder Encoder) {
+	l.out.mu.Lock()
+	l.out.encoder = encoder
+	l.out.mu.Unlock()
+}
+
+// returns a child logger sharing the output with the fields appended
+func (l *StandardLogger) With(keyvals ...interface{}) Logger {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return &StandardLogger{
+		Logger:    l.Logger,
+		CallDepth: l.CallDepth,
+		level:     l.level,
+		fields:    appendFields(l.fields, keyvals...),
+		out:       l.out,
+	}
+}
+func (l *StandardLogger) WithFields(fields map[string]interface{}) Logger {
+	return l.With(mapToKeyvals(fields)...)
 }
 
 func (l *StandardLogger) GetLevel() string {
@@ -61,13 +90,61 @@ func (l *StandardLogger) setlevel(level int) {
 	defer l.mu.Unlock()
 	l.level = level
 }
+func (l *StandardLogger) enabled(level int) bool {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.level <= level
+}
 func (l *StandardLogger) LogLine(level int, args ...interface{}) error {
 	l.mu.RLock()
 	defer l.mu.RUnlock()
-	if l.level <= level {
-		return l.Output(l.CallDepth, Levels[level]+" "+fmt.Sprintln(args...))
+	if l.level > level {
+		return nil
 	}
-	return nil
+	l.out.mu.Lock()
+	defer l.out.mu.Unlock()
+	if l.out.closed {
+		return nil
+	}
+	rw, isRecordWriter := l.out.w.(RecordWriter)
+	if l.out.encoder == nil && !isRecordWriter {
+		return l.Output(l.CallDepth, withFields(Levels[level]+" "+fmt.Sprintln(args...), l.fields))
+	}
+	// LogLine sits one frame below Output so the depth is one less
+	r := newRecord(l.CallDepth-1, level, "", fmt.Sprintln(args...), l.fields)
+	if isRecordWriter {
+		return writeRecordTo(rw, r, l.out.encoder)
+	}
+	_, err := l.out.w.Write(l.out.encoder.Encode(r))
+	return err
+}
+
+// write a prebuilt record using its caller instead of computing one
+func (l *StandardLogger) Log(r *Record) error {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	if l.level > r.Level {
+		return nil
+	}
+	if len(l.fields) > 0 {
+		r.Fields = appendFields(l.fields, fieldsToKeyvals(r.Fields)...)
+	}
+	l.out.mu.Lock()
+	defer l.out.mu.Unlock()
+	if l.out.closed {
+		return nil
+	}
+	if rw, ok := l.out.w.(RecordWriter); ok {
+		return writeRecordTo(rw, r, l.out.encoder)
+	}
+	var buf []byte
+	if l.out.encoder == nil {
+		buf = []byte(fmt.Sprintf("%s:%d: %s %s\n", r.ShortFile(), r.Line, Levels[r.Level], withFields(r.Message, r.Fields)))
+	} else {
+		buf = l.out.encoder.Encode(r)
+	}
+	_, err := l.out.w.Write(buf)
+	return err
 }
 func (l *StandardLogger) Output(calldepth int, s string) error {
 	return l.Logger.Output(calldepth, s)
@@ -163,7 +240,6 @@ func Errorf(format string, args ...interface{}) error {
 }
 func Criticalf(format string, args ...interface{}) error {
 	return Std.Criticalf(format, args...)
-	return fmt.Errorf(format, args...)
 }
 
 // methods to implement the Logger interface
@@ -176,34 +252,37 @@ func (l *StandardLogger) SetLevel(level string) error {
 	return nil
 }
 
+// stops writing, the writer is flushed but not closed as other loggers may share it
 func (l *StandardLogger) Close() {
+	l.out.close()
 }
 func (l *StandardLogger) Closed() bool {
-	return false
+	return l.out.isClosed()
 }
 func (l *StandardLogger) Flush() {
+	l.out.flush()
 }
 
 func (l *StandardLogger) IsTrace() bool {
-	return l.level <= TRACE
+	return l.enabled(TRACE)
 }
 
 func (l *StandardLogger) IsDebug() bool {
-	return l.level <= DEBUG
+	return l.enabled(DEBUG)
 }
 
 func (l *StandardLogger) IsInfo() bool {
-	return l.level <= INFO
+	return l.enabled(INFO)
 }
 
 func (l *StandardLogger) IsWarn() bool {
-	return l.level <= WARNING
+	return l.enabled(WARNING)
 }
 
 func (l *StandardLogger) IsError() bool {
-	return l.level <= ERROR
+	return l.enabled(ERROR)
 }
 
 func (l *StandardLogger) IsCritical() bool {
-	return l.level <= CRITICAL
+	return l.enabled(CRITICAL)
 }
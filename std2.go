@@ -6,7 +6,6 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"log"
 	"os"
 	"sync"
 )
@@ -16,12 +15,67 @@ type Std2Logger struct {
 	mu        sync.RWMutex
 	level     int
 	name      string
-	trace     *log.Logger
-	debug     *log.Logger
-	info      *log.Logger
-	warn      *log.Logger
-	err       *log.Logger
-	critical  *log.Logger
+	fields    []Field
+	out       *output
+}
+
+// writer and encoder shared between a logger and the children made by With
+type output struct {
+	mu      sync.Mutex
+	w       io.Writer
+	encoder Encoder
+	closed  bool
+}
+
+// writers which buffer or can commit to disk, eg. RotatingFile or *os.File
+type flusher interface {
+	Flush() error
+}
+type syncer interface {
+	Sync() error
+}
+
+func (o *output) write(r *Record) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.closed {
+		return nil
+	}
+	if rw, ok := o.w.(RecordWriter); ok {
+		return writeRecordTo(rw, r, o.encoder)
+	}
+	_, err := o.w.Write(o.encoder.Encode(r))
+	return err
+}
+
+func (o *output) flush() error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	switch w := o.w.(type) {
+	case flusher:
+		return w.Flush()
+	case syncer:
+		// stderr and stdout can not be synced when they are a terminal or pipe
+		if w == os.Stderr || w == os.Stdout {
+			return nil
+		}
+		return w.Sync()
+	}
+	return nil
+}
+
+// the writer is shared with other loggers so it is flushed but left open
+func (o *output) close() {
+	o.flush()
+	o.mu.Lock()
+	o.closed = true
+	o.mu.Unlock()
+}
+
+func (o *output) isClosed() bool {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.closed
 }
 
 // standard logger
@@ -38,18 +92,57 @@ func NewStd2Logger3(level string, name string) *Std2Logger {
 		CallDepth: 2,
 		level:     lvl,
 		name:      name,
+		out:       &output{w: os.Stderr, encoder: NewTextEncoder()},
 	}
-	l.SetWriter(os.Stderr)
 	return l
 }
 
 func (l *Std2Logger) SetWriter(out io.Writer) {
-	l.trace = log.New(out, l.name+" TRACE ", log.Lshortfile)
-	l.debug = log.New(out, l.name+" DEBUG ", log.Lshortfile)
-	l.info = log.New(out, l.name+" INFO ", log.Lshortfile)
-	l.warn = log.New(out, l.name+" WARN ", log.Lshortfile)
-	l.err = log.New(out, l.name+" ERROR ", log.Lshortfile)
-	l.critical = log.New(out, l.name+" CRITICAL ", log.Lshortfile)
+	l.out.mu.Lock()
+	l.out.w = out
+	l.out.mu.Unlock()
+}
+
+// set the output format, nil restores the default text layout
+func (l *Std2Logger) SetEncoder(encoder Encoder) {
+	if encoder == nil {
+		encoder = NewTextEncoder()
+	}
+	l.out.mu.Lock()
+	l.out.encoder = encoder
+	l.out.mu.Unlock()
+}
+
+// write a prebuilt record, the logger name and fields are added to it
+func (l *Std2Logger) Log(r *Record) error {
+	if !l.enabled(r.Level) {
+		return nil
+	}
+	r.Name = l.name
+	if len(l.fields) > 0 {
+		r.Fields = appendFields(l.fields, fieldsToKeyvals(r.Fields)...)
+	}
+	return l.out.write(r)
+}
+
+func (l *Std2Logger) output(calldepth int, level int, msg string) error {
+	return l.out.write(newRecord(calldepth+1, level, l.name, msg, l.fields))
+}
+
+// returns a child logger sharing the output with the fields appended
+func (l *Std2Logger) With(keyvals ...interface{}) Logger {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return &Std2Logger{
+		CallDepth: l.CallDepth,
+		level:     l.level,
+		name:      l.name,
+		fields:    appendFields(l.fields, keyvals...),
+		out:       l.out,
+	}
+}
+func (l *Std2Logger) WithFields(fields map[string]interface{}) Logger {
+	return l.With(mapToKeyvals(fields)...)
 }
 
 func (l *Std2Logger) GetLevel() string {
@@ -62,43 +155,48 @@ func (l *Std2Logger) setlevel(level int) {
 	defer l.mu.Unlock()
 	l.level = level
 }
+func (l *Std2Logger) enabled(level int) bool {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.level <= level
+}
 
 // print style functions
 func (l *Std2Logger) Trace(args ...interface{}) {
-	if l.level <= TRACE {
-		l.trace.Output(l.CallDepth, fmt.Sprintln(args...))
+	if l.enabled(TRACE) {
+		l.output(l.CallDepth, TRACE, fmt.Sprintln(args...))
 	}
 }
 func (l *Std2Logger) Debug(args ...interface{}) {
-	if l.level <= DEBUG {
-		l.debug.Output(l.CallDepth, fmt.Sprintln(args...))
+	if l.enabled(DEBUG) {
+		l.output(l.CallDepth, DEBUG, fmt.Sprintln(args...))
 	}
 }
 func (l *Std2Logger) Info(args ...interface{}) {
-	if l.level <= INFO {
-		l.info.Output(l.CallDepth, fmt.Sprintln(args...))
+	if l.enabled(INFO) {
+		l.output(l.CallDepth, INFO, fmt.Sprintln(args...))
 	}
 }
 func (l *Std2Logger) Warn(args ...interface{}) error {
-	if l.level <= WARNING {
+	if l.enabled(WARNING) {
 		msg := fmt.Sprintln(args...)
-		l.warn.Output(l.CallDepth, msg)
+		l.output(l.CallDepth, WARNING, msg)
 		return errors.New(msg)
 	}
 	return errors.New(fmt.Sprintln(args...))
 }
 func (l *Std2Logger) Error(args ...interface{}) error {
-	if l.level <= ERROR {
+	if l.enabled(ERROR) {
 		msg := fmt.Sprintln(args...)
-		l.err.Output(l.CallDepth, msg)
+		l.output(l.CallDepth, ERROR, msg)
 		return errors.New(msg)
 	}
 	return errors.New(fmt.Sprintln(args...))
 }
 func (l *Std2Logger) Critical(args ...interface{}) error {
-	if l.level <= CRITICAL {
+	if l.enabled(CRITICAL) {
 		msg := fmt.Sprintln(args...)
-		l.critical.Output(l.CallDepth, msg)
+		l.output(l.CallDepth, CRITICAL, msg)
 		return errors.New(msg)
 	}
 	return errors.New(fmt.Sprintln(args...))
@@ -106,40 +204,40 @@ func (l *Std2Logger) Critical(args ...interface{}) error {
 
 // printf style functions
 func (l *Std2Logger) Tracef(format string, args ...interface{}) {
-	if l.level <= TRACE {
-		l.trace.Output(l.CallDepth, fmt.Sprintf(format, args...))
+	if l.enabled(TRACE) {
+		l.output(l.CallDepth, TRACE, fmt.Sprintf(format, args...))
 	}
 }
 func (l *Std2Logger) Debugf(format string, args ...interface{}) {
-	if l.level <= DEBUG {
-		l.debug.Output(l.CallDepth, fmt.Sprintf(format, args...))
+	if l.enabled(DEBUG) {
+		l.output(l.CallDepth, DEBUG, fmt.Sprintf(format, args...))
 	}
 }
 func (l *Std2Logger) Infof(format string, args ...interface{}) {
-	if l.level <= INFO {
-		l.info.Output(l.CallDepth, fmt.Sprintf(format, args...))
+	if l.enabled(INFO) {
+		l.output(l.CallDepth, INFO, fmt.Sprintf(format, args...))
 	}
 }
 func (l *Std2Logger) Warnf(format string, args ...interface{}) error {
-	if l.level <= WARNING {
+	if l.enabled(WARNING) {
 		msg := fmt.Sprintf(format, args...)
-		l.warn.Output(l.CallDepth, msg)
+		l.output(l.CallDepth, WARNING, msg)
 		return errors.New(msg)
 	}
 	return fmt.Errorf(format, args...)
 }
 func (l *Std2Logger) Errorf(format string, args ...interface{}) error {
-	if l.level <= ERROR {
+	if l.enabled(ERROR) {
 		msg := fmt.Sprintf(format, args...)
-		l.err.Output(l.CallDepth, msg)
+		l.output(l.CallDepth, ERROR, msg)
 		return errors.New(msg)
 	}
 	return fmt.Errorf(format, args...)
 }
 func (l *Std2Logger) Criticalf(format string, args ...interface{}) error {
-	if l.level <= CRITICAL {
+	if l.enabled(CRITICAL) {
 		msg := fmt.Sprintf(format, args...)
-		l.critical.Output(l.CallDepth, msg)
+		l.output(l.CallDepth, CRITICAL, msg)
 		return errors.New(msg)
 	}
 	return fmt.Errorf(format, args...)
@@ -155,34 +253,37 @@ func (l *Std2Logger) SetLevel(level string) error {
 	return nil
 }
 
+// stops writing, the writer is flushed but not closed as other loggers may share it
 func (l *Std2Logger) Close() {
+	l.out.close()
 }
 func (l *Std2Logger) Closed() bool {
-	return false
+	return l.out.isClosed()
 }
 func (l *Std2Logger) Flush() {
+	l.out.flush()
 }
 
 func (l *Std2Logger) IsTrace() bool {
-	return l.level <= TRACE
+	return l.enabled(TRACE)
 }
 
 func (l *Std2Logger) IsDebug() bool {
-	return l.level <= DEBUG
+	return l.enabled(DEBUG)
 }
 
 func (l *Std2Logger) IsInfo() bool {
-	return l.level <= INFO
+	return l.enabled(INFO)
 }
 
 func (l *Std2Logger) IsWarn() bool {
-	return l.level <= WARNING
+	return l.enabled(WARNING)
 }
 
 func (l *Std2Logger) IsError() bool {
-	return l.level <= ERROR
+	return l.enabled(ERROR)
 }
 
 func (l *Std2Logger) IsCritical() bool {
-	return l.level <= CRITICAL
+	return l.enabled(CRITICAL)
 }
@@ -0,0 +1,98 @@
+package logging
+
+import (
+	"log"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RedirectStdLog sends everything written with the standard library log package to
+// the registered logger name at level. with parseLevel a leading level token such as
+// "[ERROR]", "ERROR:" or "ERROR " selects the level of each line instead.
+// the logger is looked up on every line so DisableLog and level changes apply.
+// call restore to put the log package back the way it was
+func RedirectStdLog(name string, level string, parseLevel bool) (restore func(), err error) {
+	lvl, err := LevelFromString(level)
+	if err != nil {
+		return nil, err
+	}
+	if GetLogger(name) == nil {
+		createLogger(name, nil)
+	}
+
+	out, flags, prefix := log.Writer(), log.Flags(), log.Prefix()
+	// Lshortfile gives us the caller of log.Printf to parse back out
+	log.SetFlags(log.Lshortfile)
+	log.SetPrefix("")
+	log.SetOutput(&stdLogWriter{name: name, level: lvl, parseLevel: parseLevel})
+
+	restore = func() {
+		log.SetOutput(out)
+		log.SetFlags(flags)
+		log.SetPrefix(prefix)
+	}
+	return restore, nil
+}
+
+type stdLogWriter struct {
+	name       string
+	level      int
+	parseLevel bool
+}
+
+func (w *stdLogWriter) Write(p []byte) (int, error) {
+	l := GetLogger(w.name)
+	if l == nil {
+		return len(p), nil
+	}
+	r := &Record{
+		Time:  time.Now(),
+		Level: w.level,
+		File:  "???",
+	}
+	line := strings.TrimSuffix(string(p), "\n")
+	if i := strings.Index(line, ": "); i > 0 {
+		if j := strings.LastIndexByte(line[:i], ':'); j > 0 {
+			if n, err := strconv.Atoi(line[j+1 : i]); err == nil {
+				r.File, r.Line = line[:j], n
+				line = line[i+2:]
+			}
+		}
+	}
+	if w.parseLevel {
+		if lvl, rest, ok := parseLevelToken(line); ok {
+			r.Level, line = lvl, rest
+		}
+	}
+	r.Message = line
+	if !isEnabled(l, r.Level) {
+		return len(p), nil
+	}
+	logRecord(l, r)
+	return len(p), nil
+}
+
+// recognise "[LEVEL] msg", "LEVEL: msg" and "LEVEL msg" using the names in Constants
+func parseLevelToken(line string) (int, string, bool) {
+	s := strings.TrimLeft(line, " ")
+	var token, rest string
+	if strings.HasPrefix(s, "[") {
+		end := strings.IndexByte(s, ']')
+		if end < 0 {
+			return 0, line, false
+		}
+		token, rest = s[1:end], s[end+1:]
+	} else {
+		end := strings.IndexAny(s, ": ")
+		if end < 0 {
+			return 0, line, false
+		}
+		token, rest = s[:end], strings.TrimPrefix(s[end:], ":")
+	}
+	lvl, ok := Constants[strings.ToUpper(token)]
+	if !ok {
+		return 0, line, false
+	}
+	return lvl, strings.TrimLeft(rest, " "), true
+}
@@ -0,0 +1,250 @@
+package logging
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type SyslogFormat int
+
+const (
+	RFC5424 SyslogFormat = iota
+	RFC3164
+)
+
+type SyslogFacility int
+
+// the zero value logs to FacilityUser
+const (
+	FacilityUser     SyslogFacility = 1
+	FacilityMail     SyslogFacility = 2
+	FacilityDaemon   SyslogFacility = 3
+	FacilityAuth     SyslogFacility = 4
+	FacilitySyslog   SyslogFacility = 5
+	FacilityLpr      SyslogFacility = 6
+	FacilityNews     SyslogFacility = 7
+	FacilityUucp     SyslogFacility = 8
+	FacilityCron     SyslogFacility = 9
+	FacilityAuthPriv SyslogFacility = 10
+	FacilityFTP      SyslogFacility = 11
+	FacilityLocal0   SyslogFacility = 16
+	FacilityLocal1   SyslogFacility = 17
+	FacilityLocal2   SyslogFacility = 18
+	FacilityLocal3   SyslogFacility = 19
+	FacilityLocal4   SyslogFacility = 20
+	FacilityLocal5   SyslogFacility = 21
+	FacilityLocal6   SyslogFacility = 22
+	FacilityLocal7   SyslogFacility = 23
+)
+
+// syslog severities for TRACE..CRITICAL
+func syslogSeverity(level int) int {
+	switch {
+	case level >= CRITICAL:
+		return 2
+	case level >= ERROR:
+		return 3
+	case level >= WARNING:
+		return 4
+	case level >= INFO:
+		return 6
+	}
+	return 7
+}
+
+type SyslogOptions struct {
+	// unix, unixgram, udp or tcp. empty uses the local syslog socket
+	Network string
+	Address string
+	Format  SyslogFormat
+	// defaults to FacilityUser
+	Facility SyslogFacility
+	// defaults to the program name
+	AppName string
+	// defaults to os.Hostname
+	Hostname string
+}
+
+// SyslogWriter sends each record as one syslog message. the level sets the severity
+// and the logger name is the MSGID. tcp uses octet counting framing (RFC 6587).
+// a broken connection is redialed on the next write
+//
+//	w, err := gologging.NewSyslogWriter(gologging.SyslogOptions{Network: "udp", Address: "loghost:514"})
+//	gologging.SetLogOutput(w)
+type SyslogWriter struct {
+	mu   sync.Mutex
+	opts SyslogOptions
+	conn net.Conn
+	// network actually dialed, differs from opts.Network for local sockets
+	network string
+	pid     string
+	closed  bool
+}
+
+var localSyslogSockets = []string{"/dev/log", "/var/run/syslog", "/var/run/log"}
+
+func NewSyslogWriter(opts SyslogOptions) (*SyslogWriter, error) {
+	if opts.Facility == 0 {
+		opts.Facility = FacilityUser
+	}
+	if opts.AppName == "" {
+		opts.AppName = filepath.Base(os.Args[0])
+	}
+	if opts.Hostname == "" {
+		opts.Hostname, _ = os.Hostname()
+	}
+	w := &SyslogWriter{
+		opts: opts,
+		pid:  strconv.Itoa(os.Getpid()),
+	}
+	if err := w.connect(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+func (w *SyslogWriter) connect() error {
+	if w.conn != nil {
+		w.conn.Close()
+		w.conn = nil
+	}
+	var networks, addrs []string
+	switch w.opts.Network {
+	case "":
+		networks = []string{"unixgram", "unix"}
+		addrs = localSyslogSockets
+		if w.opts.Address != "" {
+			addrs = []string{w.opts.Address}
+		}
+	case "unix":
+		// datagram sockets are what syslog daemons usually listen on
+		networks = []string{"unixgram", "unix"}
+		addrs = []string{w.opts.Address}
+	default:
+		networks = []string{w.opts.Network}
+		addrs = []string{w.opts.Address}
+	}
+	var err error
+	for _, addr := range addrs {
+		for _, network := range networks {
+			var conn net.Conn
+			conn, err = net.DialTimeout(network, addr, 5*time.Second)
+			if err == nil {
+				w.conn, w.network = conn, network
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("SyslogWriter: %s", err)
+}
+
+// Write sends p as one message at INFO severity
+func (w *SyslogWriter) Write(p []byte) (int, error) {
+	r := &Record{Time: time.Now(), Level: INFO, Message: strings.TrimSuffix(string(p), "\n")}
+	if err := w.WriteRecord(r); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (w *SyslogWriter) WriteRecord(r *Record) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return os.ErrClosed
+	}
+	msg := w.format(r)
+	if w.conn != nil {
+		if err := w.send(msg); err == nil {
+			return nil
+		}
+	}
+	// reconnect once, the daemon may have restarted
+	if err := w.connect(); err != nil {
+		return err
+	}
+	return w.send(msg)
+}
+
+func (w *SyslogWriter) send(msg []byte) error {
+	var err error
+	switch w.network {
+	case "tcp", "tcp4", "tcp6":
+		_, err = fmt.Fprintf(w.conn, "%d %s", len(msg), msg)
+	case "unix":
+		_, err = w.conn.Write(append(msg, '\n'))
+	default:
+		_, err = w.conn.Write(msg)
+	}
+	return err
+}
+
+func (w *SyslogWriter) format(r *Record) []byte {
+	pri := int(w.opts.Facility)*8 + syslogSeverity(r.Level)
+	msg := withFields(r.Message, r.Fields)
+	var b bytes.Buffer
+	if w.opts.Format == RFC3164 {
+		// <PRI>Mmm dd hh:mm:ss HOSTNAME TAG[PID]: MSG, the hostname is left out on the local socket
+		fmt.Fprintf(&b, "<%d>%s ", pri, r.Time.Format(time.Stamp))
+		if w.network != "unix" && w.network != "unixgram" {
+			b.WriteString(w.opts.Hostname)
+			b.WriteByte(' ')
+		}
+		fmt.Fprintf(&b, "%s[%s]: ", w.opts.AppName, w.pid)
+		if r.Name != "" {
+			b.WriteString(r.Name)
+			b.WriteString(": ")
+		}
+		b.WriteString(msg)
+		return b.Bytes()
+	}
+	// <PRI>1 TIMESTAMP HOSTNAME APP-NAME PROCID MSGID STRUCTURED-DATA MSG
+	fmt.Fprintf(&b, "<%d>1 %s %s %s %s %s - %s",
+		pri,
+		r.Time.Format("2006-01-02T15:04:05.000000Z07:00"),
+		syslogHeaderField(w.opts.Hostname, 255),
+		syslogHeaderField(w.opts.AppName, 48),
+		w.pid,
+		syslogHeaderField(r.Name, 32),
+		msg)
+	return b.Bytes()
+}
+
+// header fields are printable ascii without spaces, "-" when empty
+func syslogHeaderField(s string, max int) string {
+	s = strings.Map(func(r rune) rune {
+		if r <= ' ' || r > '~' {
+			return '_'
+		}
+		return r
+	}, s)
+	if len(s) > max {
+		s = s[:max]
+	}
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+func (w *SyslogWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+	if w.conn == nil {
+		return nil
+	}
+	err := w.conn.Close()
+	w.conn = nil
+	return err
+}
@@ -0,0 +1,185 @@
+package logging
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testRecord(level int, name string, msg string) *Record {
+	return &Record{Time: time.Now(), Level: level, Name: name, Message: msg}
+}
+
+// <PRI>1 TIMESTAMP HOSTNAME APP-NAME PROCID MSGID - MSG
+var rfc5424 = regexp.MustCompile(`(?s)^<(\d+)>1 \S+ (\S+) (\S+) (\d+) (\S+) - (.*)$`)
+
+func checkRFC5424(t *testing.T, msg string, pri int, msgid string, text string) {
+	t.Helper()
+	m := rfc5424.FindStringSubmatch(msg)
+	if m == nil {
+		t.Fatalf("not RFC 5424: %q", msg)
+	}
+	if m[1] != strconv.Itoa(pri) {
+		t.Errorf("PRI: %s, want %d", m[1], pri)
+	}
+	if m[2] != "testhost" || m[3] != "testapp" {
+		t.Errorf("HOSTNAME APP-NAME: %s %s", m[2], m[3])
+	}
+	if m[5] != msgid {
+		t.Errorf("MSGID: %s, want %s", m[5], msgid)
+	}
+	if m[6] != text {
+		t.Errorf("MSG: %q, want %q", m[6], text)
+	}
+}
+
+func TestSyslogUDP(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pc.Close()
+	w, err := NewSyslogWriter(SyslogOptions{
+		Network:  "udp",
+		Address:  pc.LocalAddr().String(),
+		Facility: FacilityLocal0,
+		AppName:  "testapp",
+		Hostname: "testhost",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	tests := []struct {
+		level    int
+		severity int
+	}{
+		{TRACE, 7}, {DEBUG, 7}, {INFO, 6}, {WARNING, 4}, {ERROR, 3}, {CRITICAL, 2},
+	}
+	buf := make([]byte, 4096)
+	for _, tt := range tests {
+		r := testRecord(tt.level, "db.pool", "hello")
+		r.Fields = []Field{{Key: "k", Value: 1}}
+		if err := w.WriteRecord(r); err != nil {
+			t.Fatal(err)
+		}
+		pc.SetReadDeadline(time.Now().Add(5 * time.Second))
+		n, _, err := pc.ReadFrom(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkRFC5424(t, string(buf[:n]), 16*8+tt.severity, "db.pool", "hello k=1")
+	}
+}
+
+func TestSyslogTCPFraming(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	w, err := NewSyslogWriter(SyslogOptions{
+		Network:  "tcp",
+		Address:  l.Addr().String(),
+		AppName:  "testapp",
+		Hostname: "testhost",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	conn, err := l.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a newline in the message is kept, the octet count delimits it
+	w.WriteRecord(testRecord(ERROR, "", "two\nlines"))
+	w.WriteRecord(testRecord(INFO, "http", "next"))
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	rd := bufio.NewReader(conn)
+	for _, want := range []struct {
+		pri   int
+		msgid string
+		text  string
+	}{
+		{1*8 + 3, "-", "two\nlines"},
+		{1*8 + 6, "http", "next"},
+	} {
+		count, err := rd.ReadString(' ')
+		if err != nil {
+			t.Fatal(err)
+		}
+		n, err := strconv.Atoi(strings.TrimSuffix(count, " "))
+		if err != nil {
+			t.Fatalf("octet count: %q", count)
+		}
+		msg := make([]byte, n)
+		if _, err := io.ReadFull(rd, msg); err != nil {
+			t.Fatal(err)
+		}
+		checkRFC5424(t, string(msg), want.pri, want.msgid, want.text)
+	}
+
+	// the writer dials again once the connection is gone
+	conn.Close()
+	accepted := make(chan net.Conn, 1)
+	go func() {
+		c, err := l.Accept()
+		if err == nil {
+			accepted <- c
+		}
+	}()
+	deadline := time.After(5 * time.Second)
+	for {
+		w.WriteRecord(testRecord(INFO, "", "again"))
+		select {
+		case c := <-accepted:
+			c.Close()
+			return
+		case <-deadline:
+			t.Fatal("no reconnect")
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
+
+func TestSyslogUnixgramRFC3164(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "log.sock")
+	pc, err := net.ListenPacket("unixgram", path)
+	if err != nil {
+		t.Skip(err)
+	}
+	defer pc.Close()
+	w, err := NewSyslogWriter(SyslogOptions{
+		Network:  "unix",
+		Address:  path,
+		Format:   RFC3164,
+		Facility: FacilityDaemon,
+		AppName:  "testapp",
+		Hostname: "testhost",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	w.WriteRecord(testRecord(WARNING, "cache", "evicted"))
+	buf := make([]byte, 4096)
+	pc.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, _, err := pc.ReadFrom(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the hostname is left out on a local socket
+	want := regexp.MustCompile(`^<28>\w{3} [ \d]\d \d\d:\d\d:\d\d testapp\[\d+\]: cache: evicted$`)
+	if !want.Match(buf[:n]) {
+		t.Errorf("RFC 3164: %q", buf[:n])
+	}
+}
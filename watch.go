@@ -0,0 +1,165 @@
+package logging
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// WatchConfig applies the config file and polls it every interval, re-applying it
+// when its modification time, size or inode changes. what changed is logged at
+// INFO by the go-logging logger. an edit which does not parse or validate is
+// logged at ERROR and the running config is kept. call stop to end the watch
+//
+//	stop, err := gologging.WatchConfig("/etc/app/logging.json", 5*time.Second)
+func WatchConfig(filename string, interval time.Duration) (stop func(), err error) {
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+	info, err := os.Stat(filename)
+	if err != nil {
+		return nil, fmt.Errorf("WatchConfig: %s", err)
+	}
+	if err := ApplyConfigFile(filename); err != nil {
+		return nil, err
+	}
+	w := &configWatcher{
+		filename: filename,
+		info:     info,
+		done:     make(chan struct{}),
+	}
+	w.wg.Add(1)
+	go w.run(interval)
+	var once sync.Once
+	stop = func() {
+		once.Do(func() {
+			close(w.done)
+			w.wg.Wait()
+		})
+	}
+	return stop, nil
+}
+
+type configWatcher struct {
+	filename string
+	info     os.FileInfo
+	done     chan struct{}
+	wg       sync.WaitGroup
+}
+
+func (w *configWatcher) run(interval time.Duration) {
+	defer w.wg.Done()
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-w.done:
+			return
+		case <-t.C:
+			w.check()
+		}
+	}
+}
+
+func (w *configWatcher) check() {
+	info, err := os.Stat(w.filename)
+	if err != nil {
+		// an editor replacing the file, try again next time
+		return
+	}
+	if os.SameFile(info, w.info) && info.ModTime().Equal(w.info.ModTime()) && info.Size() == w.info.Size() {
+		return
+	}
+	w.info = info
+
+	log := libraryLogger()
+	c, err := LoadConfig(w.filename)
+	if err == nil {
+		err = c.Validate()
+	}
+	if err != nil {
+		log.Errorf("config %s rejected, keeping the running config: %s", w.filename, err)
+		return
+	}
+	changes := diffConfig(CurrentConfig(), c)
+	if len(changes) == 0 {
+		return
+	}
+	if err := ApplyConfig(c); err != nil {
+		log.Errorf("config %s rejected, keeping the running config: %s", w.filename, err)
+		return
+	}
+	log.Infof("config %s reloaded: %s", w.filename, strings.Join(changes, ", "))
+}
+
+// the logger used for messages from the library itself
+func libraryLogger() Logger {
+	if log := GetLogger("go-logging"); log != nil {
+		return log
+	}
+	return Register("go-logging", nil)
+}
+
+// describe the differences between two configs, nil when they are the same
+func diffConfig(old, c *Config) []string {
+	if old == nil {
+		old = &Config{}
+	}
+	changes := []string{}
+	change := func(what, from, to string) {
+		if from == to {
+			return
+		}
+		if from == "" {
+			from = "unset"
+		}
+		if to == "" {
+			to = "unset"
+		}
+		changes = append(changes, fmt.Sprintf("%s %s -> %s", what, from, to))
+	}
+	change("level", old.Level, c.Level)
+	change("format", old.Format, c.Format)
+
+	names := []string{}
+	for name := range old.Levels {
+		names = append(names, name)
+	}
+	for name := range c.Levels {
+		if _, found := old.Levels[name]; !found {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		change("levels["+name+"]", old.Levels[name], c.Levels[name])
+	}
+
+	if !reflect.DeepEqual(old.Outputs, c.Outputs) {
+		change("outputs", describeOutputs(old.Outputs), describeOutputs(c.Outputs))
+	}
+	if len(changes) == 0 {
+		return nil
+	}
+	return changes
+}
+
+func describeOutputs(outputs []OutputConfig) string {
+	s := make([]string, len(outputs))
+	for i, o := range outputs {
+		s[i] = o.Type
+		if o.Path != "" {
+			s[i] += ":" + o.Path
+		} else if o.Address != "" {
+			s[i] += ":" + o.Address
+		}
+		if o.Level != "" {
+			s[i] += "@" + o.Level
+		}
+	}
+	return "[" + strings.Join(s, " ") + "]"
+}
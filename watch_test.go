@@ -0,0 +1,102 @@
+package logging
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeConfig(t *testing.T, filename string, config string) {
+	t.Helper()
+	if err := os.WriteFile(filename, []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// poll until cond holds
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestWatchConfig(t *testing.T) {
+	resetConfig(t)
+	dir := t.TempDir()
+	filename, logfile := filepath.Join(dir, "logging.json"), filepath.Join(dir, "app.log")
+	config := `{"level": %q, "format": "text", "outputs": [{"type": "file", "path": %q}]}`
+	Register("watched", nil)
+
+	writeConfig(t, filename, fmt.Sprintf(config, "info", logfile))
+	stop, err := WatchConfig(filename, 10*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stop()
+	if got := levelOf(t, "watched"); got != "INFO" {
+		t.Fatalf("level %s, want INFO", got)
+	}
+
+	writeConfig(t, filename, fmt.Sprintf(config, "debug", logfile))
+	waitFor(t, "the reload", func() bool { return levelOf(t, "watched") == "DEBUG" })
+	waitFor(t, "the reload message", func() bool {
+		return strings.Contains(readFile(t, logfile), "reloaded: level info -> debug")
+	})
+
+	// a broken edit keeps the running config
+	writeConfig(t, filename, `{"level": "debug",`)
+	waitFor(t, "the rejected message", func() bool {
+		return strings.Contains(readFile(t, logfile), "rejected, keeping the running config")
+	})
+	if got := levelOf(t, "watched"); got != "DEBUG" {
+		t.Errorf("level %s after a broken edit", got)
+	}
+
+	stop()
+	writeConfig(t, filename, fmt.Sprintf(config, "error", logfile))
+	time.Sleep(50 * time.Millisecond)
+	if got := levelOf(t, "watched"); got != "DEBUG" {
+		t.Errorf("level %s after stop", got)
+	}
+}
+
+func TestWatchConfigMissing(t *testing.T) {
+	if _, err := WatchConfig(filepath.Join(t.TempDir(), "missing.json"), time.Second); err == nil {
+		t.Error("no error")
+	}
+}
+
+func TestDiffConfig(t *testing.T) {
+	tests := []struct {
+		old, c *Config
+		want   []string
+	}{
+		{nil, &Config{}, nil},
+		{&Config{Level: "info"}, &Config{Level: "info"}, nil},
+		{nil, &Config{Level: "info", Format: "json"}, []string{"level unset -> info", "format unset -> json"}},
+		{
+			&Config{Levels: map[string]string{"a": "debug", "b": "info"}},
+			&Config{Levels: map[string]string{"b": "off", "c": "trace"}},
+			[]string{"levels[a] debug -> unset", "levels[b] info -> off", "levels[c] unset -> trace"},
+		},
+		{
+			&Config{Outputs: []OutputConfig{{Type: "stderr"}}},
+			&Config{Outputs: []OutputConfig{{Type: "file", Path: "/tmp/a.log", Level: "error"}, {Type: "syslog", Address: "loghost:514"}}},
+			[]string{"outputs [stderr] -> [file:/tmp/a.log@error syslog:loghost:514]"},
+		},
+	}
+	for i, tt := range tests {
+		if got := diffConfig(tt.old, tt.c); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%d: got %q, want %q", i, got, tt.want)
+		}
+	}
+}